@@ -1,103 +1,195 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/pegasus-kv/release-cli/release"
 	"github.com/urfave/cli"
-	git "gopkg.in/src-d/go-git.v4"
-	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
-var repoArg = ""
 var branchArg = ""
+var continueArg = false
+var abortArg = false
+var skipArg = false
 
 // ./release-cli add
 var addCommand *cli.Command = &cli.Command{
 	Name:  "add",
 	Usage: "Specify the pull-requests to merge to release branch",
 	Flags: []cli.Flag{
-		cli.StringFlag{
-			Name:        "repo",
-			Usage:       "The path where the git repository locates. /home/pegasus eg.",
-			Required:    true,
-			Destination: &repoArg,
-		},
+		repoFlag,
 		cli.StringFlag{
 			Name:        "branch",
 			Usage:       "The release branch for cherry-picks. v1.12 eg.",
-			Required:    true,
 			Destination: &branchArg,
 		},
+		cli.BoolFlag{
+			Name:        "continue",
+			Usage:       "Continue the interrupted cherry-picks after the conflict is resolved",
+			Destination: &continueArg,
+		},
+		cli.BoolFlag{
+			Name:        "skip",
+			Usage:       "Skip the pull-request that is in conflict and continue the rest",
+			Destination: &skipArg,
+		},
+		cli.BoolFlag{
+			Name:        "abort",
+			Usage:       "Abort the interrupted cherry-picks and restore the release branch",
+			Destination: &abortArg,
+		},
+		remoteFlag,
 	},
 	ArgsUsage: "The pull-request IDs to be merged (in the format of \"233 266 257\")",
 	Action: func(c *cli.Context) error {
-		var err error
-		var repo *git.Repository
-		if repo, err = git.PlainOpen(repoArg); err != nil {
-			return fatalError("cannot open repo '%s': %s", repoArg, err)
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+
+		resuming := 0
+		for _, b := range []bool{continueArg, skipArg, abortArg} {
+			if b {
+				resuming++
+			}
+		}
+		if resuming > 1 {
+			return usageError("--continue, --skip and --abort are mutually exclusive")
+		}
+		if resuming == 1 {
+			if dryRun {
+				return usageError("--dry-run can not be used to resume a session")
+			}
+			if len(c.Args()) != 0 {
+				return usageError("pull-requests can not be specified when resuming a session")
+			}
+			switch {
+			case continueArg:
+				err = repo.ContinueCherryPick(appContext)
+			case skipArg:
+				err = repo.SkipCherryPick(appContext)
+			default:
+				err = repo.AbortCherryPick(appContext)
+			}
+			return explainCherryPickError(err)
 		}
+		if session, err := repo.CherryPickSession(appContext); err != nil {
+			return err
+		} else if session != nil {
+			return repoError("a cherry-pick session to %s is in progress, use --continue, --skip or --abort", session.Branch)
+		}
+		if branchArg == "" {
+			return usageError("--branch is required")
+		}
+
 		// obtain the pull-requests to merge
 		var prIDs []int
 		for _, arg := range c.Args() {
 			pr, err := strconv.Atoi(arg)
 			if err != nil {
-				return fatalError("invalid PR number '%s'", arg)
+				return usageError("invalid PR number '%s'", arg)
 			}
 			prIDs = append(prIDs, pr)
 		}
 		if len(prIDs) == 0 {
-			return fatalError("no pull-request is specified")
+			return usageError("no pull-request is specified")
 		}
 
 		// obtain the official owner and name of this repo
-		origin, err := repo.Remote("origin")
-		fatalExitIfNotNil(err)
-		owner, repoName := getOwnerAndRepoFromURL(origin.Config().URLs[0])
-		fmt.Printf("Cherry-picking PRs on '%s'...\n\n", origin.Config().URLs[0])
+		remote, err := repo.Remote()
+		if err != nil {
+			return err
+		}
+		releaseBranch := repo.BranchOf(branchArg)
+		if dryRun {
+			fmt.Printf("Planning cherry-picks on '%s' (dry run)...\n\n", remote.URL)
+			return printAddPlan(repo, remote, prIDs, releaseBranch)
+		}
+		fmt.Printf("Cherry-picking PRs on '%s'...\n\n", remote.URL)
 
-		// obtain the real commit id of the pull-requests
-		checkoutBranch(repoArg, "master")
-		var prs []*gitobj.Commit
 		table := tablewriter.NewWriter(os.Stdout)
 		table.SetHeader([]string{"PR", "Commit SHA", "Title"})
 		table.SetBorder(false)
 		table.SetColWidth(60)
 		for _, prID := range prIDs {
-			commit, has := findCommitWithPRNumberInRepo(repo, prID)
-			if !has {
-				return fatalError("no such PR in the repo #%d", prID)
+			commit, err := repo.FindPRCommit(prID)
+			if err != nil {
+				return err
 			}
-			table.Append([]string{getPrName(owner, repoName, prID), commit.ID().String()[:10], getCommitTitle(commit.Message)})
-			prs = append(prs, commit)
+			table.Append([]string{remote.PRName(prID), commit.ID().String()[:10], release.CommitTitle(commit.Message)})
 		}
 		table.Render()
 		fmt.Println()
 
-		checkoutBranch(repoArg, branchArg)
-		if err = cherryPickCommits(repo, prs); err != nil {
-			return fatalError(err.Error())
-		}
-		return nil
+		return explainCherryPickError(repo.CherryPick(appContext, releaseBranch, prIDs))
 	},
 }
 
-// cherry-pick the corresponding commits to the release branch
-func cherryPickCommits(repo *git.Repository, prs []*gitobj.Commit) error {
-	for _, pr := range prs {
-		if _, found := findEqualCommitInRepo(repo, pr); found {
-			fmt.Printf("ignore pull-request '%s' since it has been cherry-picked\n", getCommitTitle(pr.Message))
-			continue
-		}
-		if err := executeCommand("cd %s; git cherry-pick %s", repoArg, pr.ID().String()); err != nil {
-			return fmt.Errorf("unable to cherry pick [%s] \"%s\"\n%s", pr.ID().String()[:10], getCommitTitle(pr.Message), err)
+// printAddPlan prints the cherry-picks that `add` would perform in order, without checking out
+// any branch.
+func printAddPlan(repo *release.Repo, remote *release.Remote, prIDs []int, branch string) error {
+	plans, err := repo.PlanCherryPick(branch, prIDs)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"#", "PR", "Commit SHA", "Title", "Plan"})
+	table.SetBorder(false)
+	table.SetColWidth(60)
+	for i, p := range plans {
+		plan := "cherry-pick"
+		if p.PickedAs != nil {
+			plan = fmt.Sprintf("ignore, already picked as %s", p.PickedAs.ID().String()[:10])
 		}
+		table.Append([]string{
+			strconv.Itoa(i + 1),
+			remote.PRName(p.PR),
+			p.Commit.ID().String()[:10],
+			release.CommitTitle(p.Commit.Message),
+			plan,
+		})
 	}
+	table.Render()
+	fmt.Println()
+	infoLog("dry run: no changes are made to branch %s", branch)
 	return nil
 }
 
-func findCommitWithPRNumberInRepo(repo *git.Repository, prNumber int) (*gitobj.Commit, bool) {
-	return findCommitContainsStrInRepo(repo, fmt.Sprintf("(#%d)", prNumber))
+// explainCherryPickError tells the user how to resume the cherry-picks if they stopped, or how to fix
+// the repository if git refused to run.
+func explainCherryPickError(err error) error {
+	var cpErr *release.CherryPickError
+	if !errors.As(err, &cpErr) {
+		switch {
+		case release.IsGitError(err, release.GitErrDirtyTree):
+			errorLog("%s", err)
+			return repoError("the worktree has uncommitted changes or untracked files, commit or remove them and retry")
+		case release.IsGitError(err, release.GitErrMissingRef):
+			errorLog("%s", err)
+			return repoError("a branch or commit is missing, fetch it from the remote and retry")
+		}
+		return err
+	}
+	errorLog("%s", cpErr)
+	session := cpErr.Session
+	switch {
+	case cpErr.Conflicted():
+		return withExitCode(exitCodeConflict, fmt.Errorf("resolve the conflict in '%s' and run \"add --continue\", "+
+			"or use \"add --skip\" to drop #%d, or \"add --abort\" to leave branch %s unchanged",
+			session.Worktree, cpErr.PR.ID, session.Branch))
+	case release.IsGitError(cpErr, release.GitErrDirtyTree):
+		return repoError("commit or remove the uncommitted changes and untracked files in '%s' and run \"add --continue\", "+
+			"or use \"add --abort\" to leave branch %s unchanged", session.Worktree, session.Branch)
+	case release.IsGitError(cpErr, release.GitErrMissingRef):
+		return repoError("the commit of #%d is missing, fetch it from the remote and run \"add --continue\", "+
+			"or use \"add --skip\" to drop it, or \"add --abort\" to leave branch %s unchanged",
+			cpErr.PR.ID, session.Branch)
+	}
+	return repoError("fix the problem in '%s' and run \"add --continue\", or use \"add --abort\" to leave branch %s unchanged",
+		session.Worktree, session.Branch)
 }
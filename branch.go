@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+var fromArg = ""
+
+// ./release-cli branch
+var branchCommand *cli.Command = &cli.Command{
+	Name:  "branch",
+	Usage: "Cut a new minor/major release branch from master",
+	Flags: []cli.Flag{
+		repoFlag,
+		cli.StringFlag{
+			Name:        "version",
+			Usage:       "The minor/major version of the new release branch. 2.0 eg.",
+			Destination: &versionArg,
+		},
+		cli.StringFlag{
+			Name:        "from",
+			Usage:       "The commit or the PR number (#233 eg.) in master to branch from. Defaults to the HEAD of master",
+			Destination: &fromArg,
+		},
+		cli.BoolFlag{
+			Name:        "push",
+			Usage:       "Push the branch and the tag to the remote",
+			Destination: &pushArg,
+		},
+		remoteFlag,
+		fetchFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if err := requireFlags(c, "version"); err != nil {
+			return err
+		}
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		// plan against the official repository, the existing branches and tags may not be pulled
+		if err := syncRemote(repo); err != nil {
+			return err
+		}
+
+		plan, err := repo.PlanReleaseBranch(versionArg, fromArg)
+		if err != nil {
+			return err
+		}
+		infoLog("create branch %s and tag %s at %s \"%s\"",
+			plan.Branch, plan.InitialTag, plan.Commit.ID().String()[:10], release.CommitTitle(plan.Commit.Message))
+		if dryRun {
+			infoLog("dry run: no branch or tag is created")
+			return nil
+		}
+
+		if err := repo.CreateReleaseBranch(plan); err != nil {
+			return err
+		}
+		if pushArg {
+			return repo.Push(appContext, plan.Branch, plan.InitialTag)
+		}
+		return nil
+	},
+}
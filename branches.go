@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+// ./release-cli branches
+var branchesCommand *cli.Command = &cli.Command{
+	Name:  "branches",
+	Usage: "List the release branches with their latest versions",
+	Flags: []cli.Flag{
+		repoFlag,
+		remoteFlag,
+		fetchFlag,
+	},
+	Action: func(c *cli.Context) error {
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		if err := syncRemote(repo); err != nil {
+			return err
+		}
+		lines, err := repo.ReleaseLines()
+		if err != nil {
+			return err
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Branch", "Latest Version", "Status", "Tip", "Days after commit"})
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetColWidth(80)
+		for i := len(lines) - 1; i >= 0; i-- { // the latest first
+			line := lines[i]
+			latest, status := "", "no version"
+			if line.LatestVersion != nil {
+				latest, status = line.LatestVersion.Original(), "released"
+				if line.LatestVersion.Prerelease() != "" {
+					status = "pre-release"
+				}
+			}
+			table.Append([]string{
+				line.Branch.Name,
+				latest,
+				status,
+				fmt.Sprintf("%s %s", line.Tip.Hash.String()[:10], release.CommitTitle(line.Tip.Message)),
+				fmt.Sprintf("%.2f", time.Since(line.Tip.Committer.When).Hours()/24),
+			})
+		}
+		fmt.Println()
+		table.Render()
+		fmt.Println()
+		return nil
+	},
+}
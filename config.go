@@ -0,0 +1,264 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/yaml.v2"
+)
+
+var repoArg = ""
+var profileArg = ""
+var githubRepoArg = ""
+
+var repoFlag = cli.StringFlag{
+	Name: "repo",
+	Usage: "The path where the git repository locates, '~/pegasus' e.g. Defaults to the repo of the profile, " +
+		"or the repository of the current directory",
+	EnvVar:      "RELEASE_CLI_REPO",
+	Destination: &repoArg,
+}
+
+// The settings are resolved in the order of: the flags, the environment variables, then the
+// configuration files. The configuration files are:
+//
+//	$XDG_CONFIG_HOME/release-cli/config.yaml  (~/.config/release-cli/config.yaml by default)
+//	<repo>/.release-cli.yaml
+//
+// where the latter takes precedence. A file may define named profiles, one for each repository
+// to release, whose settings take precedence over the top-level ones:
+//
+//	token-env: GITHUB_TOKEN
+//	profiles:
+//	  pegasus:
+//	    repo: ~/pegasus
+//	    github-repo: XiaoMi/pegasus
+//	  rdsn:
+//	    repo: ~/rdsn
+//	    label-format: "rdsn-{{.Version}}"
+
+const repoConfigFileName = ".release-cli.yaml"
+
+type settings struct {
+	// the path of the repository, ignored in <repo>/.release-cli.yaml
+	Repo   string `yaml:"repo"`
+	Remote string `yaml:"remote"`
+	// the "owner/repo" of the official repository on Github, see release.Options.GithubRepo
+	GithubRepo   string `yaml:"github-repo"`
+	MasterBranch string `yaml:"master-branch"`
+	LabelFormat  string `yaml:"label-format"`
+	// the version scheme, see release.VersionScheme. The prefixes are pointers since they could be empty.
+	BranchPrefix     *string  `yaml:"branch-prefix"`
+	TagPrefix        *string  `yaml:"tag-prefix"`
+	PrereleaseStages []string `yaml:"prerelease-stages"`
+	RCPrefix         string   `yaml:"rc-prefix"`
+	// the extractors of the PR numbers from the commit messages, see release.ParsePRExtractor
+	PRExtractors []string `yaml:"pr-extractors"`
+	// the environment variable of the access token to Github, if --access and ACCESS_TOKEN are absent
+	TokenEnv string `yaml:"token-env"`
+}
+
+// merge overrides the settings with the non-empty ones in `o`.
+func (s *settings) merge(o *settings) {
+	if o == nil {
+		return
+	}
+	if o.Repo != "" {
+		s.Repo = o.Repo
+	}
+	if o.Remote != "" {
+		s.Remote = o.Remote
+	}
+	if o.GithubRepo != "" {
+		s.GithubRepo = o.GithubRepo
+	}
+	if o.MasterBranch != "" {
+		s.MasterBranch = o.MasterBranch
+	}
+	if o.LabelFormat != "" {
+		s.LabelFormat = o.LabelFormat
+	}
+	if o.BranchPrefix != nil {
+		s.BranchPrefix = o.BranchPrefix
+	}
+	if o.TagPrefix != nil {
+		s.TagPrefix = o.TagPrefix
+	}
+	if len(o.PrereleaseStages) != 0 {
+		s.PrereleaseStages = o.PrereleaseStages
+	}
+	if o.RCPrefix != "" {
+		s.RCPrefix = o.RCPrefix
+	}
+	if len(o.PRExtractors) != 0 {
+		s.PRExtractors = o.PRExtractors
+	}
+	if o.TokenEnv != "" {
+		s.TokenEnv = o.TokenEnv
+	}
+}
+
+type configFile struct {
+	settings `yaml:",inline"`
+	// the profile to use if --profile is not specified
+	Profile  string               `yaml:"profile"`
+	Profiles map[string]*settings `yaml:"profiles"`
+}
+
+// readConfigFile returns an empty config if the file doesn't exist.
+func readConfigFile(path string) (*configFile, error) {
+	cfg := &configFile{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, usageError("unable to read config %s: %w", path, err)
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, usageError("invalid config %s: %w", path, err)
+	}
+	debugLog("loaded config %s", path)
+	return cfg, nil
+}
+
+func getGlobalConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "release-cli", "config.yaml")
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// findRepoOfWorkingDir returns the root of the repository containing the current directory,
+// or empty if there's none.
+func findRepoOfWorkingDir() string {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return ""
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return ""
+	}
+	return wt.Filesystem.Root()
+}
+
+// loadConfig resolves the settings of the command from the flags, the environment variables
+// and the configuration files, and applies them to the flag variables.
+func loadConfig(c *cli.Context) (*settings, error) {
+	global, err := readConfigFile(getGlobalConfigPath())
+	if err != nil {
+		return nil, err
+	}
+	profile := profileArg
+	if profile == "" {
+		profile = global.Profile
+	}
+
+	// the repository must be known before reading its config
+	if !c.IsSet("repo") {
+		s := global.settings
+		s.merge(global.Profiles[profile])
+		repoArg = expandHome(s.Repo)
+		if repoArg == "" {
+			repoArg = findRepoOfWorkingDir()
+		}
+	}
+	if repoArg == "" {
+		if profile != "" && global.Profiles[profile] == nil {
+			return nil, usageError("no such profile '%s'", profile)
+		}
+		return nil, usageError("--repo is required outside of a git repository")
+	}
+	local, err := readConfigFile(filepath.Join(repoArg, repoConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+	if profileArg == "" && local.Profile != "" {
+		profile = local.Profile
+	}
+
+	s := &settings{}
+	s.merge(&global.settings)
+	s.merge(&local.settings)
+	if profile != "" {
+		if global.Profiles[profile] == nil && local.Profiles[profile] == nil {
+			return nil, usageError("no such profile '%s'", profile)
+		}
+		s.merge(global.Profiles[profile])
+		s.merge(local.Profiles[profile])
+	}
+
+	if !c.IsSet("remote") && s.Remote != "" {
+		remoteArg = s.Remote
+	}
+	if githubRepoArg == "" {
+		githubRepoArg = s.GithubRepo
+	}
+	if accessToken == "" && s.TokenEnv != "" {
+		accessToken = os.Getenv(s.TokenEnv)
+	}
+	return s, nil
+}
+
+// the repository opened by the command, its cache is saved when the command exits
+var openedRepo *release.Repo
+
+// openRepo opens the repository of the command, see loadConfig.
+func openRepo(c *cli.Context) (*release.Repo, error) {
+	s, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	conventions := release.DefaultConventions()
+	if s.MasterBranch != "" {
+		conventions.MasterBranch = s.MasterBranch
+	}
+	if s.LabelFormat != "" {
+		conventions.LabelFormat = s.LabelFormat
+	}
+	if s.BranchPrefix != nil {
+		conventions.Scheme.BranchPrefix = *s.BranchPrefix
+	}
+	if s.TagPrefix != nil {
+		conventions.Scheme.TagPrefix = *s.TagPrefix
+	}
+	if len(s.PrereleaseStages) != 0 {
+		conventions.Scheme.PrereleaseStages = s.PrereleaseStages
+	}
+	if s.RCPrefix != "" {
+		conventions.Scheme.RCPrefix = s.RCPrefix
+	}
+	for _, spec := range s.PRExtractors {
+		e, err := release.ParsePRExtractor(spec)
+		if err != nil {
+			return nil, err
+		}
+		conventions.PRExtractors = append(conventions.PRExtractors, e)
+	}
+	repo, err := release.Open(repoArg, release.Options{Remote: remoteArg, GithubRepo: githubRepoArg, Conventions: &conventions, Logger: cliLogger{}})
+	if err != nil {
+		return nil, err
+	}
+	openedRepo = repo
+	return repo, nil
+}
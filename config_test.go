@@ -0,0 +1,133 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	repoDir := filepath.Join(dir, "pegasus")
+	writeFile := func(path, content string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(dir, "config", "release-cli", "config.yaml"), `
+remote: global
+label-format: global
+token-env: RELEASE_CLI_TEST_TOKEN
+profile: pegasus
+profiles:
+  pegasus:
+    repo: `+repoDir+`
+    github-repo: XiaoMi/pegasus
+  rdsn:
+    repo: `+repoDir+`
+    label-format: rdsn
+`)
+	writeFile(filepath.Join(repoDir, repoConfigFileName), `
+remote: local
+master-branch: main
+profiles:
+  rdsn:
+    remote: local-rdsn
+`)
+
+	envs := []string{"XDG_CONFIG_HOME", "RELEASE_CLI_REPO", "RELEASE_CLI_REMOTE", "RELEASE_CLI_PROFILE",
+		"RELEASE_CLI_GITHUB_REPO", "RELEASE_CLI_TEST_TOKEN"}
+	for _, name := range envs {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+	defer func() {
+		repoArg, remoteArg, profileArg, githubRepoArg, accessToken = "", "", "", "", ""
+	}()
+
+	tests := []struct {
+		name string
+		// the global flags, followed by the flags of the command
+		args, cmdArgs []string
+		env           map[string]string
+		// the resolved remote, github-repo, label-format and master-branch
+		want  []string
+		token string
+		fails bool
+	}{
+		{name: "default profile", want: []string{"local", "XiaoMi/pegasus", "global", "main"}},
+		{name: "profile flag", args: []string{"--profile", "rdsn"}, want: []string{"local-rdsn", "", "rdsn", "main"}},
+		{name: "profile env", env: map[string]string{"RELEASE_CLI_PROFILE": "rdsn"},
+			want: []string{"local-rdsn", "", "rdsn", "main"}},
+		{name: "profile flag over env", args: []string{"--profile", "pegasus"}, env: map[string]string{"RELEASE_CLI_PROFILE": "rdsn"},
+			want: []string{"local", "XiaoMi/pegasus", "global", "main"}},
+		{name: "remote env", env: map[string]string{"RELEASE_CLI_REMOTE": "env"},
+			want: []string{"env", "XiaoMi/pegasus", "global", "main"}},
+		{name: "remote flag over env", cmdArgs: []string{"--remote", "flag"}, env: map[string]string{"RELEASE_CLI_REMOTE": "env"},
+			want: []string{"flag", "XiaoMi/pegasus", "global", "main"}},
+		{name: "github-repo flag", args: []string{"--github-repo", "XiaoMi/rdsn"},
+			want: []string{"local", "XiaoMi/rdsn", "global", "main"}},
+		{name: "github-repo env", env: map[string]string{"RELEASE_CLI_GITHUB_REPO": "XiaoMi/rdsn"},
+			want: []string{"local", "XiaoMi/rdsn", "global", "main"}},
+		{name: "repo flag", cmdArgs: []string{"--repo", repoDir}, want: []string{"local", "XiaoMi/pegasus", "global", "main"}},
+		{name: "token env", env: map[string]string{"RELEASE_CLI_TEST_TOKEN": "token"},
+			want: []string{"local", "XiaoMi/pegasus", "global", "main"}, token: "token"},
+		{name: "missing profile", args: []string{"--profile", "bogus"}, fails: true},
+	}
+	for _, tt := range tests {
+		for _, name := range envs {
+			os.Unsetenv(name)
+		}
+		os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+		for name, value := range tt.env {
+			os.Setenv(name, value)
+		}
+		repoArg, remoteArg, profileArg, githubRepoArg, accessToken = "", "", "", "", ""
+
+		var s *settings
+		app := newApp()
+		app.Writer = ioutil.Discard
+		app.ErrWriter = ioutil.Discard
+		app.Commands = []cli.Command{{
+			Name:  "config",
+			Flags: []cli.Flag{repoFlag, remoteFlag},
+			Action: func(c *cli.Context) (err error) {
+				s, err = loadConfig(c)
+				return err
+			},
+		}}
+		args := append(append(append([]string{"release-cli"}, tt.args...), "config"), tt.cmdArgs...)
+		err := app.Run(args)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("%s: loadConfig succeeded, want an error", tt.name)
+			} else if code := getExitCode(err); code != exitCodeUsage {
+				t.Errorf("%s: loadConfig exits with %d (%s), want %d", tt.name, code, err, exitCodeUsage)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: loadConfig failed: %s", tt.name, err)
+			continue
+		}
+		got := []string{remoteArg, githubRepoArg, s.LabelFormat, s.MasterBranch}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || repoArg != repoDir || accessToken != tt.token {
+			t.Errorf("%s: loadConfig = %v in %s with token %q, want %v in %s with token %q", tt.name, got, repoArg,
+				accessToken, tt.want, repoDir, tt.token)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+// ./release-cli diff v1.12.1 v1.12.3
+var diffCommand *cli.Command = &cli.Command{
+	Name:  "diff",
+	Usage: "To show the pull requests that are changed between two versions",
+	Flags: []cli.Flag{
+		repoFlag,
+		remoteFlag,
+		fetchFlag,
+	},
+	ArgsUsage: "The two versions to compare, in the format of \"v1.11.6 v1.12.0\"",
+	Action: func(c *cli.Context) error {
+		if len(c.Args()) != 2 {
+			return usageError("two versions are required, got %d", len(c.Args()))
+		}
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		remote, err := repo.Remote()
+		if err != nil {
+			return err
+		}
+		if err := syncRemote(repo); err != nil {
+			return err
+		}
+
+		diff, err := repo.DiffVersions(c.Args()[0], c.Args()[1])
+		if err != nil {
+			return err
+		}
+		infoLog("comparing %s with %s", diff.To.Original(), diff.From.Original())
+
+		var tableBulk [][]string
+		appendRows := func(commits []*release.Commit, change string) {
+			for _, commit := range commits {
+				if commit.PR == 0 {
+					warnLog("ignore invalid commit: \"%s\"", commit.Title)
+					continue
+				}
+				tableBulk = append(tableBulk, []string{remote.PRName(commit.PR), commit.PRTitle, change})
+			}
+		}
+		appendRows(diff.Added, "added")
+		appendRows(diff.Reverted, "reverted")
+		appendRows(diff.Removed, "only in "+diff.From.Original())
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{fmt.Sprintf("PR (%d TOTAL)", len(tableBulk)), "TITLE", "Change"})
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetColWidth(120)
+		table.AppendBulk(tableBulk)
+		fmt.Println()
+		table.Render()
+		fmt.Println()
+		return nil
+	},
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+// The exit codes of release-cli.
+const (
+	// unclassified errors
+	exitCodeError = 1
+	// invalid flags or arguments
+	exitCodeUsage = 2
+	// the repository is not in the expected state, a missing branch or tag e.g.
+	exitCodeRepo = 3
+	// failed to access Github
+	exitCodeGithub = 4
+	// the cherry-picks stopped in conflict, see `add --continue`
+	exitCodeConflict = 5
+)
+
+// exitError attaches an exit code to the error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+func usageError(format string, a ...interface{}) error {
+	return withExitCode(exitCodeUsage, fmt.Errorf(format, a...))
+}
+
+// onUsageError prints the help and reports the invalid flags as usage errors, see cli.OnUsageErrorFunc.
+func onUsageError(c *cli.Context, err error, isSubcommand bool) error {
+	if c.Command.Name == "" {
+		_ = cli.ShowAppHelp(c)
+	} else {
+		_ = cli.ShowCommandHelp(c, c.Command.Name)
+	}
+	return withExitCode(exitCodeUsage, err)
+}
+
+// requireFlags returns a usage error if any of the flags is not specified.
+func requireFlags(c *cli.Context, names ...string) error {
+	for _, name := range names {
+		if !c.IsSet(name) {
+			return usageError("--%s is required", name)
+		}
+	}
+	return nil
+}
+
+func repoError(format string, a ...interface{}) error {
+	return withExitCode(exitCodeRepo, fmt.Errorf(format, a...))
+}
+
+// getExitCode returns the exit code of the outermost exitError in the chain, or the code for the
+// kind of the release errors.
+func getExitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	switch release.GetErrorKind(err) {
+	case release.ErrInvalidArgument:
+		return exitCodeUsage
+	case release.ErrRepoState:
+		return exitCodeRepo
+	case release.ErrGithub:
+		return exitCodeGithub
+	}
+	return exitCodeError
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+// ./release-cli find 459 466
+var findCommand *cli.Command = &cli.Command{
+	Name:  "find",
+	Usage: "To find the releases that contain the given pull requests",
+	Flags: []cli.Flag{
+		repoFlag,
+		remoteFlag,
+		fetchFlag,
+	},
+	ArgsUsage: "The pull-request IDs to be found (in the format of \"233 266 257\")",
+	Action: func(c *cli.Context) error {
+		var prIDs []int
+		for _, arg := range c.Args() {
+			pr, err := strconv.Atoi(arg)
+			if err != nil {
+				return usageError("invalid PR number '%s'", arg)
+			}
+			prIDs = append(prIDs, pr)
+		}
+		if len(prIDs) == 0 {
+			return usageError("no pull-request is specified")
+		}
+
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		remote, err := repo.Remote()
+		if err != nil {
+			return err
+		}
+		if err := syncRemote(repo); err != nil {
+			return err
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"PR", "TITLE", "Branch", "Commit SHA", "Released in"})
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetColWidth(80)
+		for _, prID := range prIDs {
+			commit, locations, err := repo.LocatePR(prID)
+			if err != nil {
+				return err
+			}
+			title := release.CommitTitle(commit.Message)
+			if _, prTitle, ok := repo.ExtractPR(commit.Message); ok {
+				title = prTitle
+			}
+			pr := remote.PRName(prID)
+			for i := len(locations) - 1; i >= 0; i-- { // the latest branch first
+				loc := locations[i]
+				sha, released := "", "missing"
+				if loc.Commit != nil {
+					sha, released = loc.Commit.Hash.String()[:10], "unreleased"
+					if loc.Version != nil {
+						released = loc.Version.Original()
+					}
+				}
+				table.Append([]string{pr, title, loc.Branch.Name, sha, released})
+				pr, title = "", "" // printed once for each pull-request
+			}
+		}
+		fmt.Println()
+		table.Render()
+		fmt.Println()
+		return nil
+	},
+}
@@ -1,31 +1,96 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/urfave/cli"
 )
 
+var dryRun = false
+var debug = false
+
 func main() {
+	app := newApp()
+
+	// kill the running git commands on interruption
+	ctx, cancel := context.WithCancel(context.Background())
+	appContext = ctx
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupted
+		cancel()
+	}()
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, "fatal:", err)
+		os.Exit(getExitCode(err))
+	}
+}
+
+func newApp() *cli.App {
 	app := &cli.App{
 		Name:  "release-cli",
 		Usage: "Release in Pegasus's convention",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:        "dry-run",
+				Usage:       "Print what add and submit would do, without changing the repo or Github",
+				Destination: &dryRun,
+			},
+			cli.BoolFlag{
+				Name:        "debug",
+				Usage:       "Print the debug logs, how the fork points are chosen e.g.",
+				Destination: &debug,
+			},
+			cli.StringFlag{
+				Name:        "profile",
+				Usage:       "The profile in the config to use, see README",
+				EnvVar:      "RELEASE_CLI_PROFILE",
+				Destination: &profileArg,
+			},
+			cli.StringFlag{
+				Name:        "github-repo",
+				Usage:       "The owner/repo of the official repository, XiaoMi/pegasus e.g. The remote pointing at it is used if --remote is not specified",
+				EnvVar:      "RELEASE_CLI_GITHUB_REPO",
+				Destination: &githubRepoArg,
+			},
+		},
 		Commands: []cli.Command{
 			*addCommand,
 			*showCommand,
 			*submitCommand,
+			*tagCommand,
+			*branchCommand,
+			*branchesCommand,
+			*matrixCommand,
+			*findCommand,
+			*diffCommand,
+			*notesCommand,
 		},
 		Action: func(c *cli.Context) error {
+			if c.NArg() != 0 {
+				return usageError("no such command '%s'", c.Args().First())
+			}
 			return cli.ShowAppHelp(c)
 		},
-		Compiled:    time.Now(),
-		HideVersion: true,
+		After: func(c *cli.Context) error {
+			if openedRepo != nil {
+				openedRepo.SaveCache()
+			}
+			return nil
+		},
+		OnUsageError: onUsageError,
+		Compiled:     time.Now(),
+		HideVersion:  true,
 	}
-
-	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	for i := range app.Commands {
+		app.Commands[i].OnUsageError = onUsageError
 	}
+	return app
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pegasus-kv/release-cli/release"
+)
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{args: []string{"--bogus"}, code: exitCodeUsage},
+		{args: []string{"bogus"}, code: exitCodeUsage},
+		{args: []string{"show", "--bogus"}, code: exitCodeUsage},
+		{args: []string{"show", "--output", "xml"}, code: exitCodeUsage},
+		{args: []string{"tag", "--rc"}, code: exitCodeUsage},
+		{args: []string{"branch"}, code: exitCodeUsage},
+		{args: []string{"notes"}, code: exitCodeUsage},
+		{args: []string{"diff", "v1.12.0"}, code: exitCodeUsage},
+		{args: []string{"add", "--continue", "--abort"}, code: exitCodeUsage},
+		{args: []string{"show", "--repo", "/nonexistent/release-cli"}, code: exitCodeRepo},
+	}
+	for _, tt := range tests {
+		app := newApp()
+		app.Writer = ioutil.Discard
+		app.ErrWriter = ioutil.Discard
+		err := app.Run(append([]string{"release-cli"}, tt.args...))
+		if err == nil {
+			t.Errorf("release-cli %v succeeded, want exit code %d", tt.args, tt.code)
+		} else if code := getExitCode(err); code != tt.code {
+			t.Errorf("release-cli %v exits with %d (%s), want %d", tt.args, code, err, tt.code)
+		}
+	}
+}
+
+func TestGetExitCode(t *testing.T) {
+	_, releaseErr := release.ParsePRExtractor("bogus")
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{err: errors.New("unknown"), code: exitCodeError},
+		{err: usageError("--branch is required"), code: exitCodeUsage},
+		{err: repoError("no such branch"), code: exitCodeRepo},
+		{err: withExitCode(exitCodeConflict, repoError("conflict")), code: exitCodeConflict},
+		{err: releaseErr, code: exitCodeUsage},
+	}
+	for _, tt := range tests {
+		if code := getExitCode(tt.err); code != tt.code {
+			t.Errorf("getExitCode(%s) = %d, want %d", tt.err, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+var branchesArg = ""
+
+// ./release-cli matrix --branches v1.11,v1.12,v2.0
+var matrixCommand *cli.Command = &cli.Command{
+	Name:  "matrix",
+	Usage: "To show where the pull requests in master are backported across the release branches",
+	Flags: []cli.Flag{
+		repoFlag,
+		&cli.StringFlag{
+			Name:        "branches",
+			Usage:       "The comma-separated release branches, v1.11,v1.12 e.g. Defaults to all release branches",
+			Destination: &branchesArg,
+		},
+		remoteFlag,
+		fetchFlag,
+	},
+	Action: func(c *cli.Context) error {
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		remote, err := repo.Remote()
+		if err != nil {
+			return err
+		}
+		if err := syncRemote(repo); err != nil {
+			return err
+		}
+
+		var branches []string
+		for _, b := range strings.Split(branchesArg, ",") {
+			if b = strings.TrimSpace(b); b != "" {
+				branches = append(branches, b)
+			}
+		}
+		if len(branches) == 0 {
+			all, err := repo.ReleaseBranches()
+			if err != nil {
+				return err
+			}
+			for _, b := range all {
+				branches = append(branches, b.Name)
+			}
+		}
+		if len(branches) == 0 {
+			return repoError("no release branch is found")
+		}
+		infoLog("inspecting release branches %s", strings.Join(branches, ", "))
+
+		matrix, err := repo.BackportMatrix(branches)
+		if err != nil {
+			return err
+		}
+
+		var tableBulk [][]string
+		for _, row := range matrix.Rows {
+			if row.Commit.PR == 0 {
+				warnLog("ignore invalid commit: \"%s\"", row.Commit.Title)
+				continue
+			}
+			columns := []string{remote.PRName(row.Commit.PR), row.Commit.PRTitle}
+			for _, backport := range row.Backports {
+				columns = append(columns, backportState(backport))
+			}
+			tableBulk = append(tableBulk, columns)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		header := []string{fmt.Sprintf("PR (%d TOTAL)", len(tableBulk)), "TITLE"}
+		for _, b := range matrix.Branches {
+			header = append(header, b.Name)
+		}
+		fmt.Println()
+		table.SetHeader(header)
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.SetCenterSeparator("|")
+		table.SetColWidth(80)
+		table.AppendBulk(tableBulk)
+		table.Render()
+		fmt.Println()
+		return nil
+	},
+}
+
+func backportState(backport *release.Backport) string {
+	switch backport.State {
+	case release.BackportPicked:
+		return "picked"
+	case release.BackportReleased:
+		return backport.Commit.Version
+	case release.BackportForked:
+		return "before fork"
+	default:
+		return "missing"
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+var templateArg = ""
+
+// ./release-cli notes
+var notesCommand *cli.Command = &cli.Command{
+	Name:  "notes",
+	Usage: "Generate the release notes of the given version in Markdown",
+	Flags: []cli.Flag{
+		repoFlag,
+		cli.StringFlag{
+			Name:        "version",
+			Usage:       "The released version. v1.12.3 eg.",
+			Destination: &versionArg,
+		},
+		cli.StringFlag{
+			Name:        "template",
+			Usage:       "The path of a Go template file to render the notes, instead of the default one",
+			Destination: &templateArg,
+		},
+		remoteFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if err := requireFlags(c, "version"); err != nil {
+			return err
+		}
+		logOutput = os.Stderr // stdout is reserved for the notes
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+
+		tmplText := release.DefaultNotesTemplate
+		if templateArg != "" {
+			data, err := ioutil.ReadFile(templateArg)
+			if err != nil {
+				return usageError("unable to read template '%s': %w", templateArg, err)
+			}
+			tmplText = string(data)
+		}
+
+		notes, err := repo.GenerateNotes(repo.Conventions.Scheme.NormalizeTag(versionArg))
+		if err != nil {
+			return err
+		}
+		return release.RenderNotes(os.Stdout, notes, tmplText)
+	},
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var outputFormats = []string{"table", "json", "csv", "markdown"}
+
+func isValidOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// commitRecord is the stable schema of a commit in machine-readable outputs.
+// Do not rename or remove fields, only append new ones.
+type commitRecord struct {
+	PR              int     `json:"pr"`
+	PRName          string  `json:"pr_name"`
+	Title           string  `json:"title"`
+	Version         string  `json:"version"`
+	DaysAfterMerged float64 `json:"days_after_merged"`
+	Picked          bool    `json:"picked"`
+	SHA             string  `json:"sha"`
+	Author          string  `json:"author"`
+}
+
+var commitRecordHeader = []string{"pr", "pr_name", "title", "version", "days_after_merged", "picked", "sha", "author"}
+
+func (r *commitRecord) toStrings() []string {
+	return []string{
+		strconv.Itoa(r.PR),
+		r.PRName,
+		r.Title,
+		r.Version,
+		fmt.Sprintf("%.2f", r.DaysAfterMerged),
+		strconv.FormatBool(r.Picked),
+		r.SHA,
+		r.Author,
+	}
+}
+
+func (row *rowForCommit) toRecord() (*commitRecord, bool) {
+	if row.pr == 0 {
+		return nil, false
+	}
+	return &commitRecord{
+		PR:              row.pr,
+		PRName:          row.remote.PRName(row.pr),
+		Title:           row.title,
+		Version:         row.version,
+		DaysAfterMerged: row.daysAfterMerged,
+		Picked:          row.picked,
+		SHA:             row.sha,
+		Author:          row.author,
+	}, true
+}
+
+// printRows serializes the rows into the given machine-readable format.
+func printRows(w io.Writer, format string, rows []*rowForCommit) error {
+	records := []*commitRecord{} // never encode as `null`
+	for _, row := range rows {
+		r, ok := row.toRecord()
+		if !ok {
+			warnLog("ignore invalid commit: \"%s\"", row.title)
+			continue
+		}
+		records = append(records, r)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(commitRecordHeader); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(r.toStrings()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case "markdown":
+		return writeMarkdownTable(w, commitRecordHeader, records)
+	}
+	return usageError("unsupported output format: %s", format)
+}
+
+func writeMarkdownTable(w io.Writer, header []string, records []*commitRecord) error {
+	writeLine := func(cells []string) error {
+		var escaped []string
+		for _, cell := range cells {
+			escaped = append(escaped, strings.Replace(cell, "|", "\\|", -1))
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+		return err
+	}
+
+	if err := writeLine(header); err != nil {
+		return err
+	}
+	var separators []string
+	for range header {
+		separators = append(separators, "---")
+	}
+	if err := writeLine(separators); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := writeLine(r.toStrings()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
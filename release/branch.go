@@ -0,0 +1,156 @@
+package release
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// ReleaseBranchPlan is the release branch to be created by CreateReleaseBranch.
+type ReleaseBranchPlan struct {
+	Branch string
+	// the tag at the fork point, "v2.0.0-RC0" e.g, so that the later commands are able to find
+	// where the branch diverged from master
+	InitialTag string
+	Commit     *gitobj.Commit
+}
+
+// PlanReleaseBranch validates the new minor/major version, and resolves `from` to the commit in
+// master to branch from, see BranchingCommit.
+func (r *Repo) PlanReleaseBranch(ver string, from string) (*ReleaseBranchPlan, error) {
+	scheme := &r.Conventions.Scheme
+	newBranch, err := scheme.ParseBranch(ver)
+	if err != nil {
+		return nil, err
+	}
+	if has, err := r.HasBranch(newBranch.Name); err != nil {
+		return nil, err
+	} else if has {
+		return nil, repoError("release branch %s already exists", newBranch.Name)
+	}
+	branches, err := r.ReleaseBranches()
+	if err != nil {
+		return nil, err
+	}
+	if len(branches) != 0 {
+		if latest := branches[len(branches)-1]; !newBranch.GreaterThan(latest) {
+			return nil, invalidArgumentError("version %s must be greater than the existing release branch %s", newBranch.Name, latest.Name)
+		}
+	}
+
+	commit, err := r.BranchingCommit(from)
+	if err != nil {
+		return nil, err
+	}
+	return &ReleaseBranchPlan{
+		Branch:     newBranch.Name,
+		InitialTag: scheme.Tag(newBranch.Major, newBranch.Minor, 0, scheme.rc(0)),
+		Commit:     commit,
+	}, nil
+}
+
+// CreateReleaseBranch creates the branch and its initial tag locally. The tag is created first, so
+// that nothing is left behind if it fails.
+func (r *Repo) CreateReleaseBranch(plan *ReleaseBranchPlan) error {
+	branchName := plumbing.NewBranchReferenceName(plan.Branch)
+	if _, err := r.repo.Reference(branchName, false); err == nil {
+		return repoError("branch %s already exists", plan.Branch)
+	}
+	if _, err := r.repo.CreateTag(plan.InitialTag, plan.Commit.Hash, nil); err != nil {
+		return repoError("unable to create tag %s: %w", plan.InitialTag, err)
+	}
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(branchName, plan.Commit.Hash)); err != nil {
+		if delErr := r.repo.DeleteTag(plan.InitialTag); delErr != nil {
+			r.log.Warnf("unable to delete tag %s: %s", plan.InitialTag, delErr)
+		}
+		return repoError("unable to create branch %s: %w", plan.Branch, err)
+	}
+	return nil
+}
+
+// BranchingCommit resolves `from` to a commit in master. `from` could be a revision, an abbreviated
+// commit id, or a PR number like "#233". A plain number like "233" is taken as a PR number only if it's
+// not a commit id. If `from` is empty, the HEAD of master is returned.
+func (r *Repo) BranchingCommit(from string) (*gitobj.Commit, error) {
+	master, err := r.resolveRef(r.masterRef())
+	if err != nil {
+		return nil, err
+	}
+	masterHead, err := r.repo.CommitObject(master)
+	if err != nil {
+		return nil, err
+	}
+	if from == "" {
+		return masterHead, nil
+	}
+
+	if strings.HasPrefix(from, "#") {
+		prID, err := strconv.Atoi(from[1:])
+		if err != nil {
+			return nil, invalidArgumentError("invalid PR number '%s'", from)
+		}
+		return r.prCommitInMaster(prID)
+	}
+
+	var commit *gitobj.Commit
+	if hash, err := r.repo.ResolveRevision(plumbing.Revision(from)); err == nil {
+		if commit, err = r.repo.CommitObject(*hash); err != nil {
+			return nil, repoError("'%s' is not a commit: %w", from, err)
+		}
+	} else if commit, err = r.commitInMasterByPrefix(from); err != nil {
+		return nil, err
+	} else if commit == nil {
+		if prID, err := strconv.Atoi(from); err == nil {
+			return r.prCommitInMaster(prID)
+		}
+		return nil, repoError("unable to resolve '%s': no such revision", from)
+	}
+	if commit.Hash != masterHead.Hash {
+		if is, err := commit.IsAncestor(masterHead); err != nil || !is {
+			return nil, repoError("commit %s is not in master", commit.ID().String()[:10])
+		}
+	}
+	return commit, nil
+}
+
+func (r *Repo) prCommitInMaster(prID int) (*gitobj.Commit, error) {
+	commit, has, err := r.findPRCommitInRef(r.masterRef(), prID)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, repoError("no such PR in master #%d", prID)
+	}
+	return commit, nil
+}
+
+// the minimum length of the abbreviated commit ids, as `git log --abbrev-commit` prints
+const minAbbrevLength = 7
+
+// commitInMasterByPrefix returns the commit in master whose id starts with `prefix`, or nil if
+// there's none. go-git resolves the full ids only.
+func (r *Repo) commitInMasterByPrefix(prefix string) (*gitobj.Commit, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < minAbbrevLength || len(prefix) > 40 || strings.Trim(prefix, "0123456789abcdef") != "" {
+		return nil, nil
+	}
+	idx, err := r.commitIndexOf(r.masterRef())
+	if err != nil {
+		return nil, err
+	}
+	found := -1
+	for pos, c := range idx.commits {
+		if strings.HasPrefix(c.Hash.String(), prefix) {
+			if found != -1 {
+				return nil, invalidArgumentError("commit id '%s' is ambiguous", prefix)
+			}
+			found = pos
+		}
+	}
+	if found == -1 {
+		return nil, nil
+	}
+	return idx.commit(found)
+}
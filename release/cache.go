@@ -0,0 +1,216 @@
+package release
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+	gitstorer "gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// The commit index of a ref is persisted under .git/release-cli/cache, so that its history is
+// walked only once across the runs. The index is valid as long as the tip of the ref is unchanged,
+// and it's extended with the new commits if the ref moves forward linearly, by cherry-picks e.g.
+// The patch-ids are persisted as well since they never change.
+
+// bump it when the format of the cache is changed
+const commitIndexCacheVersion = 1
+
+type commitIndexFile struct {
+	Version int                    `json:"version"`
+	Tip     string                 `json:"tip"`
+	Commits []commitIndexFileEntry `json:"commits"`
+}
+
+type commitIndexFileEntry struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+}
+
+type patchIDsFile struct {
+	Version  int               `json:"version"`
+	PatchIDs map[string]string `json:"patch_ids"`
+}
+
+// cacheDir returns the directory of the cache, or empty if the cache is disabled.
+func (r *Repo) cacheDir() string {
+	if r.cacheDisabled {
+		return ""
+	}
+	storage, ok := r.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return ""
+	}
+	return filepath.Join(storage.Filesystem().Root(), "release-cli", "cache")
+}
+
+// commitIndexOf returns the index of the history of `ref`.
+func (r *Repo) commitIndexOf(ref plumbing.ReferenceName) (*commitIndex, error) {
+	tip, err := r.resolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if idx, ok := r.commitIndexes[tip]; ok {
+		return idx, nil
+	}
+
+	path := ""
+	if dir := r.cacheDir(); dir != "" {
+		path = filepath.Join(dir, filepath.FromSlash(ref.String())+".json")
+		if cached := r.loadCommitIndex(path); cached != nil && cached.tip == tip {
+			r.log.Debugf("loaded the commit index of %s from cache", ref)
+			return cached, nil
+		}
+	}
+	idx, err := r.commitIndexFrom(tip)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		r.saveCommitIndex(path, idx)
+	}
+	return idx, nil
+}
+
+// commitIndexFrom returns the index of the history starting from `from`. The indexes loaded
+// before are reused if `from` is a linear descendant of their tips.
+func (r *Repo) commitIndexFrom(from plumbing.Hash) (*commitIndex, error) {
+	if idx, ok := r.commitIndexes[from]; ok {
+		return idx, nil
+	}
+	iter, err := r.repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return nil, repoError("unable to perform git log from %s: %w", from, err)
+	}
+	idx := r.newCommitIndex(from)
+	var base *commitIndex
+	linear := true
+	err = iter.ForEach(func(c *gitobj.Commit) error {
+		if linear {
+			if known, ok := r.commitIndexes[c.Hash]; ok {
+				base = known
+				return gitstorer.ErrStop
+			}
+			// the next commit of the log is the parent only if there's one parent
+			linear = c.NumParents() == 1
+		}
+		idx.add(&indexedCommit{Hash: c.Hash, Message: c.Message, obj: c})
+		return nil
+	})
+	if err != nil {
+		return nil, repoError("unable to perform git log from %s: %w", from, err)
+	}
+	if base != nil {
+		r.log.Debugf("extend the commit index of %s with %d commits to %s",
+			base.tip.String()[:10], len(idx.commits), from.String()[:10])
+		for _, c := range base.commits {
+			idx.add(c)
+		}
+	}
+	r.commitIndexes[from] = idx
+	return idx, nil
+}
+
+// loadCommitIndex returns nil if the cache is absent or invalid.
+func (r *Repo) loadCommitIndex(path string) *commitIndex {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var file commitIndexFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != commitIndexCacheVersion || len(file.Commits) == 0 {
+		r.log.Debugf("ignore invalid cache %s", path)
+		return nil
+	}
+	tip := plumbing.NewHash(file.Tip)
+	if idx, ok := r.commitIndexes[tip]; ok {
+		return idx
+	}
+	idx := r.newCommitIndex(tip)
+	for _, c := range file.Commits {
+		idx.add(&indexedCommit{Hash: plumbing.NewHash(c.SHA), Message: c.Message})
+	}
+	r.commitIndexes[tip] = idx
+	return idx
+}
+
+func (r *Repo) saveCommitIndex(path string, idx *commitIndex) {
+	file := commitIndexFile{Version: commitIndexCacheVersion, Tip: idx.tip.String()}
+	for _, c := range idx.commits {
+		file.Commits = append(file.Commits, commitIndexFileEntry{SHA: c.Hash.String(), Message: c.Message})
+	}
+	r.writeCache(path, &file)
+}
+
+func (r *Repo) patchIDsPath() string {
+	if dir := r.cacheDir(); dir != "" {
+		return filepath.Join(dir, "patch-ids.json")
+	}
+	return ""
+}
+
+// loadPatchIDs loads the persisted patch-ids into patchIDCache for once.
+func (r *Repo) loadPatchIDs() {
+	if r.patchIDsLoaded {
+		return
+	}
+	r.patchIDsLoaded = true
+	path := r.patchIDsPath()
+	if path == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var file patchIDsFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Version != commitIndexCacheVersion {
+		r.log.Debugf("ignore invalid cache %s", path)
+		return
+	}
+	for sha, id := range file.PatchIDs {
+		r.patchIDCache[plumbing.NewHash(sha)] = id
+	}
+}
+
+// SaveCache persists the patch-ids computed since the last call, it's supposed to be called once
+// when the operations are done since the file is rewritten as a whole. The commit indexes are
+// persisted as they're built.
+func (r *Repo) SaveCache() {
+	path := r.patchIDsPath()
+	if !r.patchIDsDirty || path == "" {
+		return
+	}
+	file := patchIDsFile{Version: commitIndexCacheVersion, PatchIDs: make(map[string]string)}
+	for hash, id := range r.patchIDCache {
+		file.PatchIDs[hash.String()] = id
+	}
+	r.writeCache(path, &file)
+	r.patchIDsDirty = false
+}
+
+// writeCache writes the file atomically. The failure is ignored since the cache is optional.
+func (r *Repo) writeCache(path string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.log.Debugf("unable to encode cache %s: %s", path, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		r.log.Debugf("unable to create directory for cache %s: %s", path, err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		r.log.Debugf("unable to write cache %s: %s", path, err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		r.log.Debugf("unable to write cache %s: %s", path, err)
+	}
+}
@@ -0,0 +1,197 @@
+package release
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// hashesOf returns the commits of the index in order.
+func hashesOf(idx *commitIndex) []plumbing.Hash {
+	var hashes []plumbing.Hash
+	for _, c := range idx.commits {
+		hashes = append(hashes, c.Hash)
+	}
+	return hashes
+}
+
+func equalHashes(idx *commitIndex, want ...*gitobj.Commit) bool {
+	hashes := hashesOf(idx)
+	if len(hashes) != len(want) {
+		return false
+	}
+	for i, c := range want {
+		if hashes[i] != c.Hash {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCommitIndexFrom(t *testing.T) {
+	// master: c0 - c1 - merge - c3
+	//           \       /
+	//            s1 ----
+	tr := newTestRepo(t)
+	c0 := tr.commit("c0", map[string]string{"a.txt": lines("0")})
+	c1 := tr.commit("c1", map[string]string{"a.txt": lines("1")})
+	tr.checkout("side", c0)
+	s1 := tr.commit("s1", map[string]string{"b.txt": lines("1")})
+	tr.checkout("master", nil)
+	hash, err := tr.wt.Commit("merge", &git.CommitOptions{
+		Author:  &gitobj.Signature{Name: "test", Email: "test@example.com", When: c1.Author.When.Add(1)},
+		Parents: []plumbing.Hash{c1.Hash, s1.Hash},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merge, err := tr.r.repo.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c3 := tr.commit("c3", map[string]string{"a.txt": lines("3")})
+
+	c1Idx, err := tr.r.commitIndexFrom(c1.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalHashes(c1Idx, c1, c0) {
+		t.Errorf("commitIndexFrom(c1) = %v", hashesOf(c1Idx))
+	}
+	if again, _ := tr.r.commitIndexFrom(c1.Hash); again != c1Idx {
+		t.Errorf("commitIndexFrom(c1) is not reused")
+	}
+
+	// the index of c1 can't be extended across the merge, which brings s1
+	c3Idx, err := tr.r.commitIndexFrom(c3.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c3Idx.commits) != 5 || c3Idx.commits[0].Hash != c3.Hash || c3Idx.commits[1].Hash != merge.Hash {
+		t.Errorf("commitIndexFrom(c3) = %v, want c3, merge, and c1, s1, c0 in any order", hashesOf(c3Idx))
+	}
+	if _, ok := c3Idx.byHash[s1.Hash]; !ok {
+		t.Errorf("commitIndexFrom(c3) = %v, want s1 indexed", hashesOf(c3Idx))
+	}
+
+	// a linear descendant extends the known index
+	c4 := tr.commit("c4", map[string]string{"a.txt": lines("4")})
+	c4Idx, err := tr.r.commitIndexFrom(c4.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c4Idx.commits) != 6 || c4Idx.commits[0].Hash != c4.Hash || c4Idx.commits[1] != c3Idx.commits[0] {
+		t.Errorf("commitIndexFrom(c4) = %v, want c4 followed by the index of c3", hashesOf(c4Idx))
+	}
+}
+
+func TestCommitIndexCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fs := memfs.New()
+	repo, err := git.Init(filesystem.NewStorage(osfs.New(dir), cache.NewObjectLRUDefault()), fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every run of release-cli opens the repository again
+	reopen := func() *Repo {
+		r, err := newRepo("", repo, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	tr := &testRepo{t: t, r: reopen(), fs: fs, wt: wt}
+	master := plumbing.NewBranchReferenceName("master")
+	cachePath := filepath.Join(dir, "release-cli", "cache", "refs", "heads", "master.json")
+
+	c0 := tr.commit("c0", map[string]string{"a.txt": lines("0")})
+	c1 := tr.commit("c1", map[string]string{"a.txt": lines("1")})
+	c2 := tr.commit("c2", map[string]string{"a.txt": lines("2")})
+
+	// fromCache reports whether the commit is loaded from the cache, which has no commit object
+	fromCache := func(c *indexedCommit) bool {
+		return c.obj == nil
+	}
+
+	tests := []struct {
+		name string
+		// changes the repository before the run
+		prepare   func()
+		want      []*gitobj.Commit
+		fromCache []bool
+	}{
+		{name: "first run", want: []*gitobj.Commit{c2, c1, c0}, fromCache: []bool{false, false, false}},
+		{name: "unchanged", want: []*gitobj.Commit{c2, c1, c0}, fromCache: []bool{true, true, true}},
+		{
+			name:      "moved forward",
+			prepare:   func() { tr.commit("c3", map[string]string{"a.txt": lines("3")}) },
+			want:      []*gitobj.Commit{nil, c2, c1, c0},
+			fromCache: []bool{false, true, true, true},
+		},
+		{
+			name: "reset backward",
+			prepare: func() {
+				if err := repo.Storer.SetReference(plumbing.NewHashReference(master, c1.Hash)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      []*gitobj.Commit{c1, c0},
+			fromCache: []bool{false, false},
+		},
+		{name: "unchanged after reset", want: []*gitobj.Commit{c1, c0}, fromCache: []bool{true, true}},
+		{
+			name: "corrupted",
+			prepare: func() {
+				if err := ioutil.WriteFile(cachePath, []byte("{"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      []*gitobj.Commit{c1, c0},
+			fromCache: []bool{false, false},
+		},
+	}
+	for _, tt := range tests {
+		if tt.prepare != nil {
+			tt.prepare()
+		}
+		r := reopen()
+		idx, err := r.commitIndexOf(master)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		tip, _ := r.resolveRef(master)
+		if idx.tip != tip || len(idx.commits) != len(tt.want) {
+			t.Errorf("%s: commitIndexOf(master) = %s %v, want %d commits from %s", tt.name, idx.tip, hashesOf(idx),
+				len(tt.want), tip)
+			continue
+		}
+		for i, c := range idx.commits {
+			if tt.want[i] != nil && c.Hash != tt.want[i].Hash {
+				t.Errorf("%s: commit %d = %s, want %s", tt.name, i, c.Hash, tt.want[i].Hash)
+			}
+			if fromCache(c) != tt.fromCache[i] {
+				t.Errorf("%s: commit %d from cache = %v, want %v", tt.name, i, fromCache(c), tt.fromCache[i])
+			}
+		}
+		if cached := r.loadCommitIndex(cachePath); cached == nil || cached.tip != tip {
+			t.Errorf("%s: the cache is not updated to %s", tt.name, tip)
+		}
+	}
+}
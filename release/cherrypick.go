@@ -0,0 +1,240 @@
+package release
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// CherryPickError is returned when the cherry-picks stop at a pull-request. The session is saved,
+// and can be resumed by ContinueCherryPick, SkipCherryPick or AbortCherryPick.
+type CherryPickError struct {
+	Session *CherryPickSession
+	PR      *SessionPR
+	Err     error
+}
+
+func (e *CherryPickError) Error() string {
+	return fmt.Sprintf("unable to cherry pick [%s] \"%s\"\n%s", e.PR.SHA[:10], e.PR.Title, e.Err)
+}
+
+func (e *CherryPickError) Unwrap() error {
+	return e.Err
+}
+
+// Conflicted returns whether the cherry-pick stopped in conflict.
+func (e *CherryPickError) Conflicted() bool {
+	return IsGitError(e.Err, GitErrConflict)
+}
+
+// CherryPickPlan is what CherryPick would do for a pull-request.
+type CherryPickPlan struct {
+	PR     int
+	Commit *gitobj.Commit
+	// the commit in the release branch if the pull-request is already cherry-picked, or nil
+	PickedAs *gitobj.Commit
+}
+
+// PlanCherryPick returns what CherryPick would do, without changing the repository.
+func (r *Repo) PlanCherryPick(branch string, prIDs []int) ([]*CherryPickPlan, error) {
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if _, err := r.resolveRef(branchRef); err != nil {
+		return nil, repoError("no such release branch: %s", branch)
+	}
+
+	var plans []*CherryPickPlan
+	for _, prID := range prIDs {
+		commit, err := r.FindPRCommit(prID)
+		if err != nil {
+			return nil, err
+		}
+		cpCommit, found, err := r.findEqualCommitInRef(branchRef, commit)
+		if err != nil {
+			return nil, err
+		}
+		plan := &CherryPickPlan{PR: prID, Commit: commit}
+		if found {
+			plan.PickedAs = cpCommit
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// CherryPick cherry-picks the pull-requests in master to the local release branch in order,
+// the pull-requests that are already picked are ignored.
+func (r *Repo) CherryPick(ctx context.Context, branch string, prIDs []int) error {
+	session, err := r.CherryPickSession(ctx)
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		return repoError("a cherry-pick session to %s is in progress, use --continue, --skip or --abort", session.Branch)
+	}
+
+	branchRef, err := r.repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		return repoError("no such release branch: %s", branch)
+	}
+	if err := r.checkBranchNotCheckedOut(ctx, branch); err != nil {
+		return err
+	}
+
+	// obtain the real commit id of the pull-requests
+	session = &CherryPickSession{Branch: branch, OrigHead: branchRef.Hash().String()}
+	for _, prID := range prIDs {
+		commit, err := r.FindPRCommit(prID)
+		if err != nil {
+			return err
+		}
+		session.PRs = append(session.PRs, &SessionPR{
+			ID:    prID,
+			SHA:   commit.ID().String(),
+			Title: CommitTitle(commit.Message),
+		})
+	}
+
+	// the history in the worktree extends the release branch, whose index is cached
+	if _, err := r.commitIndexOf(branchRef.Name()); err != nil {
+		return err
+	}
+	if session.Worktree, err = r.createWorktree(ctx, session.OrigHead); err != nil {
+		return err
+	}
+	if err = r.saveSession(ctx, session); err != nil {
+		return err
+	}
+	return r.runSession(ctx, session)
+}
+
+// runSession cherry-picks the remaining pull-requests of the session in its worktree. The session is
+// saved when a cherry-pick fails. Once all are done, the release branch is updated to the result, and
+// the session is removed.
+func (r *Repo) runSession(ctx context.Context, session *CherryPickSession) error {
+	for ; session.Current < len(session.PRs); session.Current++ {
+		pr, err := r.repo.CommitObject(plumbing.NewHash(session.PRs[session.Current].SHA))
+		if err != nil {
+			return repoError("unable to find commit for #%d: %w", session.PRs[session.Current].ID, err)
+		}
+		if err := r.cherryPickCommit(ctx, session.Worktree, pr); err != nil {
+			if saveErr := r.saveSession(ctx, session); saveErr != nil {
+				return saveErr
+			}
+			return &CherryPickError{Session: session, PR: session.PRs[session.Current], Err: err}
+		}
+		if err := r.saveSession(ctx, session); err != nil {
+			return err
+		}
+	}
+
+	newHead, err := r.worktreeHead(ctx, session.Worktree)
+	if err != nil {
+		return err
+	}
+	if err := r.updateBranch(ctx, session.Branch, newHead.String(), session.OrigHead); err != nil {
+		return err
+	}
+	r.log.Infof("all %d pull-requests are cherry-picked to %s", len(session.PRs), session.Branch)
+	if err := r.removeWorktree(ctx, session.Worktree); err != nil {
+		return err
+	}
+	return r.removeSession(ctx)
+}
+
+func (r *Repo) sessionInProgress(ctx context.Context) (*CherryPickSession, error) {
+	session, err := r.CherryPickSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, invalidArgumentError("no cherry-pick session is in progress")
+	}
+	return session, nil
+}
+
+// ContinueCherryPick continues the session after the conflict is resolved in the worktree.
+func (r *Repo) ContinueCherryPick(ctx context.Context) error {
+	session, err := r.sessionInProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if r.isCherryPickInProgress(ctx, session.Worktree) {
+		_, err := r.runGit(ctx, session.Worktree, "-c", "core.editor=true", "cherry-pick", "--continue")
+		switch {
+		case IsGitError(err, GitErrEmptyCommit):
+			// the conflict is resolved with no changes, drop the empty cherry-pick
+			r.log.Infof("#%d is empty after resolving the conflict, skip it", session.PRs[session.Current].ID)
+			if _, err := r.runGit(ctx, session.Worktree, "cherry-pick", "--abort"); err != nil {
+				return err
+			}
+		case err != nil:
+			return &CherryPickError{Session: session, PR: session.PRs[session.Current], Err: err}
+		}
+		session.Current++
+	}
+	// otherwise the current pull-request failed before being cherry-picked, retry it
+	return r.runSession(ctx, session)
+}
+
+// SkipCherryPick drops the pull-request where the session stopped, and continues the rest.
+func (r *Repo) SkipCherryPick(ctx context.Context) error {
+	session, err := r.sessionInProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if r.isCherryPickInProgress(ctx, session.Worktree) {
+		// the previous cherry-picks are committed, aborting only drops the current one
+		if _, err := r.runGit(ctx, session.Worktree, "cherry-pick", "--abort"); err != nil {
+			return err
+		}
+	}
+	r.log.Infof("skip #%d \"%s\"", session.PRs[session.Current].ID, session.PRs[session.Current].Title)
+	session.Current++
+	return r.runSession(ctx, session)
+}
+
+// AbortCherryPick drops the session, the release branch is left unchanged.
+func (r *Repo) AbortCherryPick(ctx context.Context) error {
+	session, err := r.sessionInProgress(ctx)
+	if err != nil {
+		return err
+	}
+	// the release branch is not updated until the session is done
+	if err := r.removeWorktree(ctx, session.Worktree); err != nil {
+		return err
+	}
+	r.log.Infof("branch %s is left unchanged at %s", session.Branch, session.OrigHead[:10])
+	return r.removeSession(ctx)
+}
+
+// cherry-pick the corresponding commit to the HEAD of the worktree, the returned error is a GitError
+// if the cherry-pick fails
+func (r *Repo) cherryPickCommit(ctx context.Context, worktree string, pr *gitobj.Commit) error {
+	head, err := r.worktreeHead(ctx, worktree)
+	if err != nil {
+		return err
+	}
+	_, found, err := r.findEqualCommitFrom(head, pr)
+	if err != nil {
+		return err
+	}
+	if found {
+		r.log.Infof("ignore pull-request '%s' since it has been cherry-picked", CommitTitle(pr.Message))
+		return nil
+	}
+	// -x records the "(cherry picked from commit <sha>)" trailer, which is matched first in the later analysis
+	args := []string{"cherry-pick", "-x", pr.ID().String()}
+	if pr.NumParents() > 1 {
+		// a merged pull-request, pick the changes against the mainline
+		args = []string{"cherry-pick", "-x", "-m", "1", pr.ID().String()}
+	}
+	_, err = r.runGit(ctx, worktree, args...)
+	if IsGitError(err, GitErrEmptyCommit) {
+		// the changes are already in the branch, but not detected as a cherry-pick
+		r.log.Infof("ignore pull-request '%s' since its changes are already applied", CommitTitle(pr.Message))
+		_, err = r.runGit(ctx, worktree, "cherry-pick", "--abort")
+	}
+	return err
+}
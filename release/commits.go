@@ -0,0 +1,278 @@
+package release
+
+import (
+	"strings"
+	"time"
+
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Commit is a commit in master or in a release branch.
+type Commit struct {
+	Title string
+	// the pull-request of the commit, 0 if the commit doesn't refer to any, see Conventions.PRExtractors
+	PR int
+	// the title of the pull-request, which is the commit title without the PR number e.g.
+	PRTitle string
+	// The version where the commit is released, "cherry-picked" if it's not released yet in a
+	// release branch, or empty in master.
+	Version         string
+	DaysAfterMerged float64
+	SHA             string
+	Author          string
+}
+
+// ForkPoint returns the commit in master where the release branch was forked. It's the merge-base of
+// master and the release branch. If there's none, which happens when the release branch is re-created
+// by cherry-picks e.g, it's the counterpart in master of the latest commit in the release branch
+// that has one, searching from the initial version of the branch.
+func (r *Repo) ForkPoint(releaseBranch string) (*gitobj.Commit, error) {
+	fork, _, err := r.forkPoint(releaseBranch)
+	return fork, err
+}
+
+// forkPoint returns the fork point in master, and its counterpart in the release branch, which is the fork
+// point itself unless the branch is re-created.
+func (r *Repo) forkPoint(releaseBranch string) (masterCommit, branchCommit *gitobj.Commit, err error) {
+	masterHead, err := r.commitForRef(r.masterRef())
+	if err != nil {
+		return nil, nil, err
+	}
+	branchHead, err := r.commitForRef(r.branchRef(releaseBranch))
+	if err != nil {
+		return nil, nil, repoError("no such release branch: %s", releaseBranch)
+	}
+
+	bases, err := masterHead.MergeBase(branchHead)
+	if err != nil {
+		return nil, nil, repoError("unable to compute the merge-base of %s and %s: %w", r.Conventions.MasterBranch, releaseBranch, err)
+	}
+	if len(bases) != 0 {
+		base := bases[0]
+		for _, b := range bases[1:] {
+			if b.Committer.When.After(base.Committer.When) {
+				base = b
+			}
+		}
+		if len(bases) > 1 {
+			var shas []string
+			for _, b := range bases {
+				shas = append(shas, b.Hash.String()[:10])
+			}
+			r.log.Debugf("%s and %s have %d merge-bases: %s, choose the latest one", r.Conventions.MasterBranch,
+				releaseBranch, len(bases), strings.Join(shas, ", "))
+		}
+		r.log.Debugf("the fork point of %s is the merge-base of %s (%s) and %s (%s): %s \"%s\"", releaseBranch,
+			r.Conventions.MasterBranch, masterHead.Hash.String()[:10], releaseBranch, branchHead.Hash.String()[:10],
+			base.Hash.String()[:10], CommitTitle(base.Message))
+		return base, base, nil
+	}
+
+	// fallback to the heuristic for the histories that are not related
+	initialVer, err := r.InitialVersionInBranch(releaseBranch)
+	if err != nil {
+		return nil, nil, err
+	}
+	commit, err := r.commitForTag(initialVer)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.log.Debugf("%s and %s have no merge-base, search backwards from the initial version %s (%s) for a commit "+
+		"equal to one in %s", r.Conventions.MasterBranch, releaseBranch, initialVer, commit.Hash.String()[:10],
+		r.Conventions.MasterBranch)
+	for {
+		masterCommit, has, err := r.findEqualCommitInRef(r.masterRef(), commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		if has {
+			r.log.Debugf("the fork point of %s is %s \"%s\", which is equal to %s in %s", releaseBranch,
+				masterCommit.Hash.String()[:10], CommitTitle(masterCommit.Message), commit.Hash.String()[:10], releaseBranch)
+			return masterCommit, commit, nil
+		}
+		r.log.Debugf("commit %s \"%s\" in %s has no counterpart in %s, step back", commit.Hash.String()[:10],
+			CommitTitle(commit.Message), releaseBranch, r.Conventions.MasterBranch)
+		if commit.NumParents() == 0 {
+			return nil, nil, repoError("unable to find where %s is forked from %s: no commit in %s is equal to one in %s",
+				releaseBranch, r.Conventions.MasterBranch, releaseBranch, r.Conventions.MasterBranch)
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return nil, nil, repoError("unable to find parent for commit %s: %w", commit.Hash, err)
+		}
+		commit = parent
+	}
+}
+
+// UnreleasedCommits returns the commits reside in master but not cherry-picked to the release branch.
+func (r *Repo) UnreleasedCommits(releaseBranch string) ([]*Commit, error) {
+	masterDivergedCommit, branchDivergedCommit, err := r.forkPoint(releaseBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	r.log.Debugf("start scanning master branch")
+	commits, err := r.commitsInBranchFrom(r.Conventions.MasterBranch, masterDivergedCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	pickedCommits, limit, err := r.releaseBranchIndexSince(releaseBranch, branchDivergedCommit)
+	if err != nil {
+		return nil, err
+	}
+	var notPicked []*Commit
+	for _, c := range commits {
+		picked, err := pickedCommits.hasEqualCommit(c, limit)
+		if err != nil {
+			return nil, err
+		}
+		if !picked {
+			notPicked = append(notPicked, c)
+		}
+	}
+	return notPicked, nil
+}
+
+// PickedCommits returns all commits that are cherry-picked in `upcomingBranch` after `pastReleasedVer`.
+// `pastReleasedVer` must not be a pre-released version.
+func (r *Repo) PickedCommits(pastReleasedVer string, upcomingBranch string) ([]*Commit, error) {
+	releaseBranch := r.BranchOf(pastReleasedVer)
+
+	if releaseBranch == upcomingBranch {
+		startingCommit, err := r.commitForTag(pastReleasedVer)
+		if err != nil {
+			return nil, err
+		}
+		return r.commitsInBranchFrom(releaseBranch, startingCommit)
+	}
+
+	// If it's an upcoming minor release (branched from master).
+
+	// The commits between the two diverged points (1.11.0-RC1, 1.12.0-RC1 e.g)
+	// but not in the previous release branch (1.11), are certainly in the new minor version (1.12.0).
+	//
+	//                <-|/
+	//                <-|------- 1.12.0-RC1
+	//                <-|
+	// 1.12.0 commits <-| /----- 1.11.6
+	//                  |/
+	//                  |------- 1.11.0-RC1
+	//                  |
+
+	// Firstly collect all the commits in the previous release branch (1.11 in the above example),
+	// aka commits between the fork point of 1.11 and 1.11.6.
+	divergedCommit, prevDivergedCommit, err := r.forkPoint(releaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	prevCommits, prevLimit, err := r.releaseBranchIndexSince(releaseBranch, prevDivergedCommit)
+	if err != nil {
+		return nil, err
+	}
+	r.log.Infof("the diverged point of master and %s is %s", releaseBranch, divergedCommit.Hash.String()[:10])
+
+	newCommits, err := r.commitsInBranchFrom(upcomingBranch, divergedCommit)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Commit
+	for _, c := range newCommits {
+		// those not in v1.11 are certainly belong to v1.12
+		picked, err := prevCommits.hasEqualCommit(c, prevLimit)
+		if err != nil {
+			return nil, err
+		}
+		if !picked {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// CommitsInReleaseBranch returns the commits (sorted by time order) within release branch.
+func (r *Repo) CommitsInReleaseBranch(branch string) ([]*Commit, error) {
+	_, divergedCommit, err := r.forkPoint(branch)
+	if err != nil {
+		return nil, err
+	}
+	return r.commitsInBranchFrom(branch, divergedCommit)
+}
+
+// releaseBranchIndexSince returns the index of the release branch, where the first `limit` commits are
+// those after `divergedCommit`, the counterpart of its fork point in the branch, see forkPoint.
+func (r *Repo) releaseBranchIndexSince(branch string, divergedCommit *gitobj.Commit) (idx *commitIndex, limit int, err error) {
+	idx, limit, ok, err := r.branchIndexSince(branch, divergedCommit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, repoError("commit %s is not in the history of %s", divergedCommit.Hash.String()[:10], branch)
+	}
+	return idx, limit, nil
+}
+
+// branchIndexSince returns the index of the branch, where the first `limit` commits are those after
+// `since` in the order of `git log`. `ok` is false if `since` is not in the history of the branch.
+func (r *Repo) branchIndexSince(branch string, since *gitobj.Commit) (idx *commitIndex, limit int, ok bool, err error) {
+	if idx, err = r.commitIndexOf(r.branchRef(branch)); err != nil {
+		return nil, 0, false, err
+	}
+	limit, ok = idx.byHash[since.Hash]
+	return idx, limit, ok, nil
+}
+
+// Get commits starting from `startingCommit` (sorted by time order) within branch (could be a master branch).
+func (r *Repo) commitsInBranchFrom(branch string, startingCommit *gitobj.Commit) ([]*Commit, error) {
+
+	currentVersion := ""
+	var versions map[string]string
+	if branch != r.Conventions.MasterBranch {
+		var err error
+		if versions, err = r.mapCommitTitleToVersion(branch); err != nil {
+			return nil, err
+		}
+		currentVersion = "cherry-picked"
+	}
+	var commits []*Commit
+	handler := func(c *gitobj.Commit) {
+		commitTitle := CommitTitle(c.Message)
+		if ver, ok := versions[commitTitle]; ok {
+			currentVersion = ver
+		}
+		commits = append(commits, r.newCommit(c, currentVersion))
+	}
+
+	idx, limit, ok, err := r.branchIndexSince(branch, startingCommit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// the histories are unrelated, walk until the ancestors of `startingCommit`
+		if err := r.forEachGitLogUntil(r.branchRef(branch), handler, startingCommit); err != nil {
+			return nil, err
+		}
+		return commits, nil
+	}
+	for pos := 0; pos < limit; pos++ {
+		c, err := idx.commit(pos)
+		if err != nil {
+			return nil, err
+		}
+		handler(c)
+	}
+	return commits, nil
+}
+
+func (r *Repo) newCommit(c *gitobj.Commit, version string) *Commit {
+	pr, prTitle, _ := r.ExtractPR(c.Message)
+	return &Commit{
+		Title:           CommitTitle(c.Message),
+		PR:              pr,
+		PRTitle:         prTitle,
+		Version:         version,
+		DaysAfterMerged: time.Since(c.Committer.When).Hours() / 24,
+		SHA:             c.Hash.String(),
+		Author:          c.Author.Name,
+	}
+}
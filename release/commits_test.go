@@ -0,0 +1,127 @@
+package release
+
+import (
+	"testing"
+
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// prsOf returns the PR numbers of the commits, and their versions.
+func prsOf(commits []*Commit) (prs []int, versions []string) {
+	for _, c := range commits {
+		prs = append(prs, c.PR)
+		versions = append(versions, c.Version)
+	}
+	return prs, versions
+}
+
+func TestCommitsOfReleaseBranch(t *testing.T) {
+	// master: init(v1.0.0) - #2 - #3
+	//             \
+	// v1.1:        #2 (cherry-picked, v1.1.0-RC1) - #4 (cherry-picked)
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("a")})
+	tr.tag("v1.0.0", base)
+	tr.checkout("v1.0", base)
+	tr.checkout("master", nil)
+	pr2 := tr.commit("feat: b (#2)", map[string]string{"b.txt": lines("b")})
+	tr.commit("feat: c (#3)", map[string]string{"c.txt": lines("c")})
+	pr4 := tr.commit("feat: d (#4)", map[string]string{"d.txt": lines("d")})
+	tr.checkout("v1.1", base)
+	pick2 := tr.commit("feat: b (#2)\n\n(cherry picked from commit "+pr2.Hash.String()+")",
+		map[string]string{"b.txt": lines("b")})
+	// a cherry-pick before the initial version
+	tr.tag("v1.1.0-RC1", pick2)
+	tr.commit("feat: d (#4)\n\n(cherry picked from commit "+pr4.Hash.String()+")", map[string]string{"d.txt": lines("d")})
+
+	fork, err := tr.r.ForkPoint("v1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fork.Hash != base.Hash {
+		t.Errorf("ForkPoint(v1.1) = %s, want %s", fork.Hash, base.Hash)
+	}
+
+	unreleased, err := tr.r.UnreleasedCommits("v1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prs, _ := prsOf(unreleased); len(prs) != 1 || prs[0] != 3 {
+		t.Errorf("UnreleasedCommits(v1.1) = %v, want [3]", prs)
+	}
+
+	picked, err := tr.r.PickedCommits("v1.0.0", "v1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs, versions := prsOf(picked)
+	if len(prs) != 2 || prs[0] != 4 || prs[1] != 2 || versions[0] != "cherry-picked" || versions[1] != "v1.1.0-RC1" {
+		t.Errorf("PickedCommits(v1.0.0, v1.1) = %v %v, want [4 2] [cherry-picked v1.1.0-RC1]", prs, versions)
+	}
+
+	inBranch, err := tr.r.CommitsInReleaseBranch("v1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prs, _ := prsOf(inBranch); len(prs) != 2 || prs[0] != 4 || prs[1] != 2 {
+		t.Errorf("CommitsInReleaseBranch(v1.1) = %v, want [4 2]", prs)
+	}
+}
+
+func TestForkPoint(t *testing.T) {
+	// master: init - #2 - #3
+	//             \
+	// v1.1:        #4(v1.1.0-RC1)
+	// v1.2: (re-created) init' - #2' (same patch, another title) - v1.2-only(v1.2.0-RC1)
+	// v1.3: (unrelated) v1.3-only(v1.3.0-RC1)
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("a")})
+	pr2 := tr.commit("feat: b (#2)", map[string]string{"b.txt": lines("b")})
+	tr.commit("feat: c (#3)", map[string]string{"c.txt": lines("c")})
+
+	tr.checkout("v1.1", base)
+	tr.tag("v1.1.0-RC1", tr.commit("feat: d (#4)", map[string]string{"d.txt": lines("d")}))
+
+	tr.orphan("v1.2")
+	tr.commit("init", map[string]string{"a.txt": lines("a")})
+	tr.commit("feat: b", map[string]string{"b.txt": lines("b")})
+	tr.tag("v1.2.0-RC1", tr.commit("chore: only in v1.2", map[string]string{"e.txt": lines("e")}))
+
+	tr.orphan("v1.3")
+	tr.tag("v1.3.0-RC1", tr.commit("chore: only in v1.3", map[string]string{"f.txt": lines("f")}))
+
+	tests := []struct {
+		branch string
+		fork   *gitobj.Commit
+		fails  bool
+	}{
+		{branch: "v1.1", fork: base},
+		{branch: "v1.2", fork: pr2},
+		{branch: "v1.3", fails: true},
+		{branch: "v1.4", fails: true},
+	}
+	for _, tt := range tests {
+		fork, err := tr.r.ForkPoint(tt.branch)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ForkPoint(%s) = %s, want an error", tt.branch, fork.Hash)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ForkPoint(%s) failed: %s", tt.branch, err)
+		} else if fork.Hash != tt.fork.Hash {
+			t.Errorf("ForkPoint(%s) = %s %q, want %s %q", tt.branch, fork.Hash, CommitTitle(fork.Message), tt.fork.Hash,
+				CommitTitle(tt.fork.Message))
+		}
+	}
+
+	// the cherry-picks are counted since the counterpart of the fork point in the re-created branch
+	unreleased, err := tr.r.UnreleasedCommits("v1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prs, _ := prsOf(unreleased); len(prs) != 1 || prs[0] != 3 {
+		t.Errorf("UnreleasedCommits(v1.2) = %v, want [3]", prs)
+	}
+}
@@ -0,0 +1,167 @@
+package release
+
+import (
+	"regexp"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// the trailer written by `git revert`
+var revertTrailerRegex = regexp.MustCompile(`This reverts commit ([0-9a-f]{40})`)
+
+// VersionDiff is the difference between two versions.
+type VersionDiff struct {
+	// the older version
+	From *Version
+	To   *Version
+	// the commits in To but not in From, excluding the reverts and the commits reverted in To
+	Added []*Commit
+	// the commits in From but not in To, excluding the reverts and the commits reverted in From
+	Removed []*Commit
+	// the commits reverted in To after From
+	Reverted []*Commit
+}
+
+// DiffVersions compares the two versions, which may be in different release branches. The versions
+// are swapped if `from` is newer than `to`.
+func (r *Repo) DiffVersions(from, to string) (*VersionDiff, error) {
+	diff := &VersionDiff{}
+	var err error
+	if diff.From, err = r.Conventions.Scheme.ParseVersion(r.Conventions.Scheme.NormalizeTag(from)); err != nil {
+		return nil, err
+	}
+	if diff.To, err = r.Conventions.Scheme.ParseVersion(r.Conventions.Scheme.NormalizeTag(to)); err != nil {
+		return nil, err
+	}
+	if diff.From.GreaterThan(diff.To) {
+		diff.From, diff.To = diff.To, diff.From
+	}
+
+	fromCommit, err := r.commitForTag(diff.From.Original())
+	if err != nil {
+		return nil, err
+	}
+	toCommit, err := r.commitForTag(diff.To.Original())
+	if err != nil {
+		return nil, err
+	}
+	fromIdx, err := r.commitIndexFrom(fromCommit.Hash)
+	if err != nil {
+		return nil, err
+	}
+	toIdx, err := r.commitIndexFrom(toCommit.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the commits after the merge-base can differ
+	fromLimit, toLimit := len(fromIdx.commits), len(toIdx.commits)
+	bases, err := fromCommit.MergeBase(toCommit)
+	if err != nil {
+		return nil, repoError("unable to compute the merge-base of %s and %s: %w", diff.From.Original(), diff.To.Original(), err)
+	}
+	for _, base := range bases {
+		if pos, ok := fromIdx.byHash[base.Hash]; ok && pos < fromLimit {
+			fromLimit = pos
+		}
+		if pos, ok := toIdx.byHash[base.Hash]; ok && pos < toLimit {
+			toLimit = pos
+		}
+	}
+	r.log.Debugf("compare %d commits in %s with %d commits in %s", fromLimit, diff.From.Original(), toLimit,
+		diff.To.Original())
+
+	toReverted, err := r.revertedCommits(toIdx, toLimit)
+	if err != nil {
+		return nil, err
+	}
+	fromReverted, err := r.revertedCommits(fromIdx, fromLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range toReverted.commits {
+		diff.Reverted = append(diff.Reverted, r.newCommit(c, ""))
+	}
+
+	for pos := 0; pos < toLimit; pos++ {
+		c, err := toIdx.commit(pos)
+		if err != nil {
+			return nil, err
+		}
+		if toReverted.has(c) {
+			continue
+		}
+		if _, found, err := fromIdx.find(c, fromLimit); err != nil {
+			return nil, err
+		} else if !found {
+			diff.Added = append(diff.Added, r.newCommit(c, ""))
+		}
+	}
+	// the commits reverted only in From are added in To if they're still there
+	for _, c := range fromReverted.commits {
+		if _, found, err := toIdx.find(c, len(toIdx.commits)); err != nil {
+			return nil, err
+		} else if found && !toReverted.has(c) {
+			diff.Added = append(diff.Added, r.newCommit(c, ""))
+		}
+	}
+	for pos := 0; pos < fromLimit; pos++ {
+		c, err := fromIdx.commit(pos)
+		if err != nil {
+			return nil, err
+		}
+		if fromReverted.has(c) {
+			continue
+		}
+		if _, found, err := toIdx.find(c, toLimit); err != nil {
+			return nil, err
+		} else if !found {
+			diff.Removed = append(diff.Removed, r.newCommit(c, ""))
+		}
+	}
+	return diff, nil
+}
+
+// reverts is the commits reverted in a history.
+type reverts struct {
+	commits []*gitobj.Commit
+	// the reverted commits and the reverts
+	hashes map[plumbing.Hash]bool
+}
+
+// revertedCommits returns the commits reverted in the first `limit` commits of the index.
+func (r *Repo) revertedCommits(idx *commitIndex, limit int) (*reverts, error) {
+	result := &reverts{hashes: make(map[plumbing.Hash]bool)}
+	for pos := 0; pos < limit; pos++ {
+		c, err := idx.commit(pos)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range revertTrailerRegex.FindAllStringSubmatch(c.Message, -1) {
+			reverted, err := r.repo.CommitObject(plumbing.NewHash(match[1]))
+			if err != nil {
+				r.log.Warnf("unable to find commit %s reverted by %s \"%s\"", match[1][:10], c.Hash.String()[:10],
+					CommitTitle(c.Message))
+				continue
+			}
+			result.hashes[c.Hash] = true
+			result.hashes[reverted.Hash] = true
+			result.commits = append(result.commits, reverted)
+		}
+	}
+	return result, nil
+}
+
+// has returns whether `c` is a revert or reverted, including the cherry-picks of the reverted commits.
+func (rv *reverts) has(c *gitobj.Commit) bool {
+	if rv.hashes[c.Hash] {
+		return true
+	}
+	for _, src := range getCherryPickSources(c) {
+		if rv.hashes[src] {
+			return true
+		}
+	}
+	return false
+}
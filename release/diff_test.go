@@ -0,0 +1,82 @@
+package release
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffVersions(t *testing.T) {
+	// master: init(v1.0.0) - #1 - #2 - #3 - #4 - revert #3 (v1.1.0)
+	//           \
+	// v1.0:      #1(v1.0.1) - #2 - revert #2 - #6(v1.0.2)
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"1.txt": lines("0"), "2.txt": lines("0"), "3.txt": lines("0")})
+	tr.tag("v1.0.0", base)
+	pr1 := tr.commit("fix: a (#1)", map[string]string{"1.txt": lines("1")})
+	pr2 := tr.commit("fix: b (#2)", map[string]string{"2.txt": lines("2")})
+	pr3 := tr.commit("feat: c (#3)", map[string]string{"3.txt": lines("3")})
+	tr.commit("fix: d (#4)", map[string]string{"4.txt": lines("4")})
+	revert3 := tr.commit(fmt.Sprintf("Revert \"feat: c (#3)\"\n\nThis reverts commit %s.", pr3.Hash),
+		map[string]string{"3.txt": lines("0")})
+	tr.tag("v1.1.0", revert3)
+	tr.checkout("v1.0", base)
+	tr.tag("v1.0.1", tr.commit("fix: a (#1)\n\n(cherry picked from commit "+pr1.Hash.String()+")",
+		map[string]string{"1.txt": lines("1")}))
+	pick2 := tr.commit("fix: b (#2)\n\n(cherry picked from commit "+pr2.Hash.String()+")",
+		map[string]string{"2.txt": lines("2")})
+	tr.commit(fmt.Sprintf("Revert \"fix: b (#2)\"\n\nThis reverts commit %s.", pick2.Hash),
+		map[string]string{"2.txt": lines("0")})
+	tr.tag("v1.0.2", tr.commit("fix: f (#6)", map[string]string{"6.txt": lines("6")}))
+
+	tests := []struct {
+		from, to string
+		// the versions after swapping
+		wantFrom, wantTo string
+		added            []int
+		removed          []int
+		reverted         []int
+	}{
+		{from: "v1.0.1", to: "v1.0.2", wantFrom: "v1.0.1", wantTo: "v1.0.2", added: []int{6}, reverted: []int{2}},
+		// #2 is reverted only in v1.0.2, so it's added in v1.1.0, and #6 is never picked to master
+		{from: "v1.0.2", to: "v1.1.0", wantFrom: "v1.0.2", wantTo: "v1.1.0", added: []int{4, 2}, removed: []int{6},
+			reverted: []int{3}},
+		{from: "v1.1.0", to: "v1.0.2", wantFrom: "v1.0.2", wantTo: "v1.1.0", added: []int{4, 2}, removed: []int{6},
+			reverted: []int{3}},
+		{from: "1.0.0", to: "1.0.1", wantFrom: "v1.0.0", wantTo: "v1.0.1", added: []int{1}},
+		{from: "v1.0.2", to: "v1.0.2", wantFrom: "v1.0.2", wantTo: "v1.0.2"},
+	}
+	for _, tt := range tests {
+		diff, err := tr.r.DiffVersions(tt.from, tt.to)
+		if err != nil {
+			t.Errorf("DiffVersions(%s, %s) failed: %s", tt.from, tt.to, err)
+			continue
+		}
+		if diff.From.Original() != tt.wantFrom || diff.To.Original() != tt.wantTo {
+			t.Errorf("DiffVersions(%s, %s) compares %s with %s, want %s with %s", tt.from, tt.to, diff.From.Original(),
+				diff.To.Original(), tt.wantFrom, tt.wantTo)
+		}
+		added, _ := prsOf(diff.Added)
+		removed, _ := prsOf(diff.Removed)
+		reverted, _ := prsOf(diff.Reverted)
+		if !equalInts(added, tt.added) || !equalInts(removed, tt.removed) || !equalInts(reverted, tt.reverted) {
+			t.Errorf("DiffVersions(%s, %s) = added %v, removed %v, reverted %v, want %v, %v, %v", tt.from, tt.to,
+				added, removed, reverted, tt.added, tt.removed, tt.reverted)
+		}
+	}
+
+	if _, err := tr.r.DiffVersions("v1.0.2", "v1.9.0"); err == nil {
+		t.Errorf("DiffVersions with a missing version succeeded")
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,297 @@
+package release
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	fdiff "gopkg.in/src-d/go-git.v4/plumbing/format/diff"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// Two commits are considered equal (one is cherry-picked from the other) if, in order of priority:
+//
+//  1. one of them has the "(cherry picked from commit <sha>)" trailer that refers to the other;
+//  2. they have the same patch-id, that is, their diffs are the same regardless of the
+//     line numbers and whitespaces, like `git patch-id`;
+//  3. they have the same title, as a fallback for the cherry-picks that were changed
+//     while resolving conflicts.
+//
+// An ambiguous match is reported if there're more than one candidates in the same priority.
+
+var cherryPickTrailerRegex = regexp.MustCompile(`\(cherry picked from commit ([0-9a-f]{40})\)`)
+
+// getCherryPickSources returns the commits from which this commit was cherry-picked.
+func getCherryPickSources(c *gitobj.Commit) []plumbing.Hash {
+	var sources []plumbing.Hash
+	for _, match := range cherryPickTrailerRegex.FindAllStringSubmatch(c.Message, -1) {
+		sources = append(sources, plumbing.NewHash(match[1]))
+	}
+	return sources
+}
+
+// patchID computes the hash of the changes introduced by the commit, ignoring the line numbers
+// and whitespaces. Returns empty if the patch-id is unavailable, for example, for a merge commit.
+func (r *Repo) patchID(c *gitobj.Commit) string {
+	r.loadPatchIDs()
+	if id, ok := r.patchIDCache[c.Hash]; ok {
+		return id
+	}
+	id := ""
+	if c.NumParents() == 1 {
+		if parent, err := c.Parent(0); err == nil {
+			if patch, err := parent.Patch(c); err == nil {
+				id = hashPatch(patch.FilePatches())
+			} else {
+				r.log.Debugf("unable to compute patch of commit %s: %s", c.Hash.String()[:10], err)
+			}
+		}
+	}
+	r.patchIDCache[c.Hash] = id
+	r.patchIDsDirty = true
+	return id
+}
+
+func hashPatch(filePatches []fdiff.FilePatch) string {
+	if len(filePatches) == 0 {
+		return "" // an empty commit equals to nothing
+	}
+	stripSpaces := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+
+	h := sha1.New()
+	for _, fp := range filePatches {
+		from, to := fp.Files()
+		if from != nil {
+			h.Write([]byte("a/" + from.Path() + "\n"))
+		}
+		if to != nil {
+			h.Write([]byte("b/" + to.Path() + "\n"))
+		}
+		if fp.IsBinary() {
+			if to != nil {
+				h.Write([]byte("binary " + to.Hash().String() + "\n"))
+			}
+			continue
+		}
+		for _, chunk := range fp.Chunks() {
+			var op string
+			switch chunk.Type() {
+			case fdiff.Add:
+				op = "+"
+			case fdiff.Delete:
+				op = "-"
+			default:
+				continue // the context lines are ignored
+			}
+			for _, line := range strings.Split(chunk.Content(), "\n") {
+				if line = stripSpaces(line); line != "" {
+					h.Write([]byte(op + line + "\n"))
+				}
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// commitIndex indexes a set of commits to find the equal commits within it. The commits are
+// kept in the order of `git log`, so that a search can be limited to a prefix of them.
+type commitIndex struct {
+	r *Repo
+	// the commit where the history is indexed from, zero if it's a set of commits
+	tip     plumbing.Hash
+	commits []*indexedCommit
+
+	byHash             map[plumbing.Hash]int
+	byCherryPickSource map[plumbing.Hash][]int
+	byTitle            map[string][]int
+	byPR               map[int][]int
+	// computed lazily since it's expensive, for the first `patchIDsIndexed` commits
+	byPatchID       map[string][]int
+	patchIDsIndexed int
+}
+
+type indexedCommit struct {
+	Hash    plumbing.Hash
+	Message string
+	// loaded lazily
+	obj *gitobj.Commit
+}
+
+func (r *Repo) newCommitIndex(tip plumbing.Hash) *commitIndex {
+	return &commitIndex{
+		r:                  r,
+		tip:                tip,
+		byHash:             make(map[plumbing.Hash]int),
+		byCherryPickSource: make(map[plumbing.Hash][]int),
+		byTitle:            make(map[string][]int),
+		byPR:               make(map[int][]int),
+		byPatchID:          make(map[string][]int),
+	}
+}
+
+func (idx *commitIndex) add(c *indexedCommit) {
+	if _, ok := idx.byHash[c.Hash]; ok {
+		return
+	}
+	pos := len(idx.commits)
+	idx.commits = append(idx.commits, c)
+	idx.byHash[c.Hash] = pos
+	for _, match := range cherryPickTrailerRegex.FindAllStringSubmatch(c.Message, -1) {
+		src := plumbing.NewHash(match[1])
+		idx.byCherryPickSource[src] = append(idx.byCherryPickSource[src], pos)
+	}
+	title := CommitTitle(c.Message)
+	idx.byTitle[title] = append(idx.byTitle[title], pos)
+	if pr, _, ok := idx.r.ExtractPR(c.Message); ok {
+		idx.byPR[pr] = append(idx.byPR[pr], pos)
+	}
+}
+
+func (idx *commitIndex) commit(pos int) (*gitobj.Commit, error) {
+	c := idx.commits[pos]
+	if c.obj == nil {
+		obj, err := idx.r.repo.CommitObject(c.Hash)
+		if err != nil {
+			return nil, repoError("unable to find commit %s: %w", c.Hash, err)
+		}
+		c.obj = obj
+	}
+	return c.obj, nil
+}
+
+// indexPatchIDs computes the patch-ids of the first `limit` commits.
+func (idx *commitIndex) indexPatchIDs(limit int) error {
+	for ; idx.patchIDsIndexed < limit; idx.patchIDsIndexed++ {
+		c, err := idx.commit(idx.patchIDsIndexed)
+		if err != nil {
+			return err
+		}
+		if id := idx.r.patchID(c); id != "" {
+			idx.byPatchID[id] = append(idx.byPatchID[id], idx.patchIDsIndexed)
+		}
+	}
+	return nil
+}
+
+// find returns the commit in the first `limit` commits of the index that is equal to `c`.
+func (idx *commitIndex) find(c *gitobj.Commit, limit int) (*gitobj.Commit, bool, error) {
+	within := func(positions []int) []int {
+		var result []int
+		for _, pos := range positions {
+			if pos < limit {
+				result = append(result, pos)
+			}
+		}
+		return result
+	}
+
+	if pos, ok := idx.byHash[c.Hash]; ok && pos < limit {
+		same, err := idx.commit(pos)
+		return same, err == nil, err
+	}
+
+	candidates := within(idx.byCherryPickSource[c.Hash])
+	for _, src := range getCherryPickSources(c) {
+		if pos, ok := idx.byHash[src]; ok && pos < limit {
+			candidates = append(candidates, pos)
+		}
+	}
+	if len(candidates) != 0 {
+		return idx.pickCandidate(c, candidates, "cherry-pick trailer")
+	}
+
+	if id := idx.r.patchID(c); id != "" {
+		if err := idx.indexPatchIDs(limit); err != nil {
+			return nil, false, err
+		}
+		if candidates = within(idx.byPatchID[id]); len(candidates) != 0 {
+			return idx.pickCandidate(c, candidates, "patch-id")
+		}
+	}
+
+	if candidates = within(idx.byTitle[CommitTitle(c.Message)]); len(candidates) != 0 {
+		idx.r.log.Debugf("commit %s \"%s\" is matched by title only", c.Hash.String()[:10], CommitTitle(c.Message))
+		return idx.pickCandidate(c, candidates, "title")
+	}
+	return nil, false, nil
+}
+
+// pickCandidate returns the first (latest) candidate, and reports if the match is ambiguous.
+func (idx *commitIndex) pickCandidate(c *gitobj.Commit, candidates []int, matchedBy string) (*gitobj.Commit, bool, error) {
+	sort.Ints(candidates)
+	if len(candidates) > 1 {
+		var shas []string
+		for _, pos := range candidates {
+			shas = append(shas, idx.commits[pos].Hash.String()[:10])
+		}
+		idx.r.log.Warnf("ambiguous match by %s for commit %s \"%s\": %s, choose %s",
+			matchedBy, c.Hash.String()[:10], CommitTitle(c.Message), strings.Join(shas, ", "), shas[0])
+	}
+	chosen, err := idx.commit(candidates[0])
+	return chosen, err == nil, err
+}
+
+// findEqualCommitFrom searches the commit history starting from `from` for the commit equal
+// to `commit`. The search stops at where the history and `commit` are diverged, since
+// a cherry-pick can never be older than that.
+func (r *Repo) findEqualCommitFrom(from plumbing.Hash, commit *gitobj.Commit) (*gitobj.Commit, bool, error) {
+	idx, err := r.commitIndexFrom(from)
+	if err != nil {
+		return nil, false, err
+	}
+	return idx.findEqualCommitInHistory(commit)
+}
+
+// findEqualCommitInHistory is findEqualCommitFrom on the indexed history.
+func (idx *commitIndex) findEqualCommitInHistory(commit *gitobj.Commit) (*gitobj.Commit, bool, error) {
+	if _, ok := idx.byHash[commit.Hash]; ok {
+		return commit, true, nil // the commit itself is in the history
+	}
+	fromCommit, err := idx.commit(0)
+	if err != nil {
+		return nil, false, err
+	}
+	limit := len(idx.commits)
+	if bases, err := fromCommit.MergeBase(commit); err == nil {
+		for _, base := range bases {
+			if pos, ok := idx.byHash[base.Hash]; ok && pos < limit {
+				limit = pos
+			}
+		}
+	}
+	return idx.find(commit, limit)
+}
+
+// newCommitIndexFromCommits loads the commits and indexes them.
+func (r *Repo) newCommitIndexFromCommits(commits []*Commit) (*commitIndex, error) {
+	idx := r.newCommitIndex(plumbing.ZeroHash)
+	for _, sc := range commits {
+		c, err := r.repo.CommitObject(plumbing.NewHash(sc.SHA))
+		if err != nil {
+			return nil, repoError("unable to find commit %s: %w", sc.SHA, err)
+		}
+		idx.add(&indexedCommit{Hash: c.Hash, Message: c.Message, obj: c})
+	}
+	return idx, nil
+}
+
+// hasEqualCommit returns whether the first `limit` commits of the index contain a commit equal to `sc`.
+func (idx *commitIndex) hasEqualCommit(sc *Commit, limit int) (bool, error) {
+	c, err := idx.r.repo.CommitObject(plumbing.NewHash(sc.SHA))
+	if err != nil {
+		return false, repoError("unable to find commit %s: %w", sc.SHA, err)
+	}
+	_, found, err := idx.find(c, limit)
+	return found, err
+}
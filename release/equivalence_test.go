@@ -0,0 +1,105 @@
+package release
+
+import (
+	"testing"
+
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestPatchID(t *testing.T) {
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("1", "2", "3", "4", "5")})
+	fix := tr.commit("fix: x (#1)", map[string]string{"a.txt": lines("1", "two", "3", "4", "5")})
+	empty := tr.commit("chore: empty", nil)
+
+	tr.checkout("v1.0", base)
+	tr.commit("chore: shift", map[string]string{"a.txt": lines("0", "0", "0", "1", "2", "3", "4", "5")})
+	// the same change at another line, with different whitespaces
+	pick := tr.commit("fix: x", map[string]string{"a.txt": lines("0", "0", "0", "1", "  two\t", "3", "4", "5")})
+	other := tr.commit("fix: x", map[string]string{"a.txt": lines("0", "0", "0", "1", "2", "three", "4", "5")})
+
+	tests := []struct {
+		name  string
+		a, b  *gitobj.Commit
+		equal bool
+	}{
+		{name: "cherry-pick", a: fix, b: pick, equal: true},
+		{name: "different change", a: fix, b: other, equal: false},
+		{name: "self", a: other, b: other, equal: true},
+	}
+	for _, tt := range tests {
+		a, b := tr.r.patchID(tt.a), tr.r.patchID(tt.b)
+		if a == "" || b == "" {
+			t.Errorf("%s: unexpected empty patch-id %q, %q", tt.name, a, b)
+		} else if (a == b) != tt.equal {
+			t.Errorf("%s: patch-id equality = %v, want %v", tt.name, a == b, tt.equal)
+		}
+	}
+	if id := tr.r.patchID(empty); id != "" {
+		t.Errorf("patch-id of an empty commit = %q, want empty", id)
+	}
+	if id := tr.r.patchID(base); id != "" {
+		t.Errorf("patch-id of the root commit = %q, want empty", id)
+	}
+	if id := hashPatch(nil); id != "" {
+		t.Errorf("hashPatch(nil) = %q, want empty", id)
+	}
+}
+
+func TestCommitIndexFind(t *testing.T) {
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("1", "2", "3"), "b.txt": lines("1")})
+	fix := tr.commit("fix: x (#1)", map[string]string{"a.txt": lines("1", "two", "3")})
+	feat := tr.commit("feat: y (#2)", map[string]string{"c.txt": lines("y")})
+	refactor := tr.commit("refactor: z (#3)", map[string]string{"b.txt": lines("one")})
+	dup1 := tr.commit("chore: w (#4)", map[string]string{"d.txt": lines("w")})
+	dup2 := tr.commit("chore: w (#4)", map[string]string{"e.txt": lines("w")})
+	idx := tr.index(dup2, dup1, refactor, feat, fix, base)
+
+	tr.checkout("v1.0", base)
+	byPatchID := tr.commit("fix: x on v1.0", map[string]string{"a.txt": lines("1", " two ", "3")})
+	// conflicts resolved differently
+	byTitle := tr.commit("feat: y (#2)", map[string]string{"c.txt": lines("y", "y")})
+	byTrailer := tr.commit("refactor: z on v1.0\n\n(cherry picked from commit "+refactor.Hash.String()+")",
+		map[string]string{"b.txt": lines("uno")})
+	ambiguous := tr.commit("chore: w (#4)", map[string]string{"f.txt": lines("w")})
+	missing := tr.commit("docs: v", map[string]string{"g.txt": lines("v")})
+	// a trailer takes priority over the title
+	trailerFirst := tr.commit("fix: x (#1)\n\n(cherry picked from commit "+feat.Hash.String()+")",
+		map[string]string{"a.txt": lines("1", "two", "3")})
+
+	tests := []struct {
+		name   string
+		commit *gitobj.Commit
+		limit  int
+		want   *gitobj.Commit
+	}{
+		{name: "itself", commit: fix, limit: 6, want: fix},
+		{name: "itself beyond the limit", commit: fix, limit: 4, want: nil},
+		{name: "patch-id", commit: byPatchID, limit: 6, want: fix},
+		{name: "patch-id beyond the limit", commit: byPatchID, limit: 4, want: nil},
+		{name: "title", commit: byTitle, limit: 6, want: feat},
+		{name: "trailer", commit: byTrailer, limit: 6, want: refactor},
+		{name: "trailer beyond the limit", commit: byTrailer, limit: 2, want: nil},
+		{name: "trailer first", commit: trailerFirst, limit: 6, want: feat},
+		{name: "latest of the ambiguous", commit: ambiguous, limit: 6, want: dup2},
+		{name: "title beyond the limit", commit: ambiguous, limit: 0, want: nil},
+		{name: "missing", commit: missing, limit: 6, want: nil},
+	}
+	for _, tt := range tests {
+		got, found, err := idx.find(tt.commit, tt.limit)
+		if err != nil {
+			t.Errorf("%s: find failed: %s", tt.name, err)
+			continue
+		}
+		if tt.want == nil {
+			if found {
+				t.Errorf("%s: find = %s %q, want not found", tt.name, got.Hash, CommitTitle(got.Message))
+			}
+			continue
+		}
+		if !found || got.Hash != tt.want.Hash {
+			t.Errorf("%s: find = %v, %v, want %s %q", tt.name, got, found, tt.want.Hash, CommitTitle(tt.want.Message))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind classifies the errors returned by this package.
+type ErrorKind int
+
+const (
+	// ErrUnknown is an unclassified error.
+	ErrUnknown ErrorKind = iota
+	// ErrInvalidArgument means the arguments are invalid, a malformed version e.g.
+	ErrInvalidArgument
+	// ErrRepoState means the repository is not in the expected state, a missing branch or tag e.g.
+	ErrRepoState
+	// ErrGithub means it failed to access Github.
+	ErrGithub
+)
+
+// Error is an error with its kind.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GetErrorKind returns the kind of the outermost Error in the chain. The failed git commands are
+// regarded as ErrRepoState.
+func GetErrorKind(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		return ErrRepoState
+	}
+	return ErrUnknown
+}
+
+func invalidArgumentError(format string, a ...interface{}) error {
+	return &Error{Kind: ErrInvalidArgument, Err: fmt.Errorf(format, a...)}
+}
+
+func repoError(format string, a ...interface{}) error {
+	return &Error{Kind: ErrRepoState, Err: fmt.Errorf(format, a...)}
+}
+
+func githubError(format string, a ...interface{}) error {
+	return &Error{Kind: ErrGithub, Err: fmt.Errorf(format, a...)}
+}
@@ -0,0 +1,74 @@
+package release
+
+import (
+	"sort"
+
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// PRLocation is where a pull-request in master is in a release branch.
+type PRLocation struct {
+	Branch *ReleaseBranch
+	// the counterpart of the pull-request in the branch, nil if it's not cherry-picked
+	Commit *gitobj.Commit
+	// the first version in the branch that contains the pull-request, nil if it's unreleased
+	Version *Version
+}
+
+// LocatePR returns the commit of the pull-request in master, and where it is in every release
+// branch in ascending order.
+func (r *Repo) LocatePR(prID int) (*gitobj.Commit, []*PRLocation, error) {
+	commit, err := r.FindPRCommit(prID)
+	if err != nil {
+		return nil, nil, err
+	}
+	branches, err := r.ReleaseBranches()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var locations []*PRLocation
+	for _, b := range branches {
+		loc := &PRLocation{Branch: b}
+		locations = append(locations, loc)
+
+		idx, err := r.commitIndexOf(r.branchRef(b.Name))
+		if err != nil {
+			return nil, nil, err
+		}
+		counterpart, found, err := idx.findEqualCommitInHistory(commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			continue
+		}
+		loc.Commit = counterpart
+		if loc.Version, err = r.firstVersionContaining(b.Name, idx, counterpart); err != nil {
+			return nil, nil, err
+		}
+	}
+	return commit, locations, nil
+}
+
+// firstVersionContaining returns the least version tagged in the release branch after `commit`,
+// or nil if there's none. `idx` is the index of the branch.
+func (r *Repo) firstVersionContaining(releaseBranch string, idx *commitIndex, commit *gitobj.Commit) (*Version, error) {
+	versions, err := r.versionsInBranch(releaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(VersionCollection(versions))
+	commitPos := idx.byHash[commit.Hash]
+	for _, v := range versions {
+		tagged, err := r.commitForTag(v.Original())
+		if err != nil {
+			return nil, err
+		}
+		// the history is in the order of `git log`, the tagged commit is after `commit` if it's in front
+		if pos, ok := idx.byHash[tagged.Hash]; ok && pos <= commitPos {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,73 @@
+package release
+
+import (
+	"testing"
+
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestLocatePR(t *testing.T) {
+	// master: init(v1.0.0) - #1 - #2 - #3
+	//           \                \
+	// v1.0:      #1 - #2(v1.0.1) - #5(v1.0.2)
+	// v1.1:                      (v1.1.0-RC0) #3
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("a")})
+	tr.tag("v1.0.0", base)
+	pr1 := tr.commit("fix: b (#1)", map[string]string{"b.txt": lines("b")})
+	pr2 := tr.commit("fix: c (#2)", map[string]string{"c.txt": lines("c")})
+	pr3 := tr.commit("fix: d (#3)", map[string]string{"d.txt": lines("d")})
+	tr.checkout("v1.0", base)
+	pick1 := tr.commit("fix: b (#1)", map[string]string{"b.txt": lines("b")})
+	pick2 := tr.commit("fix: c (#2)", map[string]string{"c.txt": lines("c")})
+	tr.tag("v1.0.1", pick2)
+	tr.tag("v1.0.2", tr.commit("fix: f (#5)", map[string]string{"f.txt": lines("f")}))
+	tr.checkout("v1.1", pr2)
+	tr.tag("v1.1.0-RC0", pr2)
+	pick3 := tr.commit("fix: d (#3)", map[string]string{"d.txt": lines("d")})
+
+	// the expected location in each branch
+	type location struct {
+		commit  *gitobj.Commit
+		version string
+	}
+	tests := []struct {
+		pr        int
+		commit    *gitobj.Commit
+		locations []location
+	}{
+		// the least version after the cherry-pick, not the initial version
+		{pr: 1, commit: pr1, locations: []location{{pick1, "v1.0.1"}, {pr1, "v1.1.0-RC0"}}},
+		{pr: 2, commit: pr2, locations: []location{{pick2, "v1.0.1"}, {pr2, "v1.1.0-RC0"}}},
+		{pr: 3, commit: pr3, locations: []location{{nil, ""}, {pick3, ""}}},
+	}
+	for _, tt := range tests {
+		commit, locations, err := tr.r.LocatePR(tt.pr)
+		if err != nil {
+			t.Errorf("LocatePR(%d) failed: %s", tt.pr, err)
+			continue
+		}
+		if commit.Hash != tt.commit.Hash {
+			t.Errorf("LocatePR(%d) = %s, want %s", tt.pr, commit.Hash, tt.commit.Hash)
+		}
+		if len(locations) != len(tt.locations) {
+			t.Errorf("LocatePR(%d) has %d locations, want %d", tt.pr, len(locations), len(tt.locations))
+			continue
+		}
+		for i, loc := range locations {
+			want := tt.locations[i]
+			version := ""
+			if loc.Version != nil {
+				version = loc.Version.Original()
+			}
+			if (loc.Commit == nil) != (want.commit == nil) || (loc.Commit != nil && loc.Commit.Hash != want.commit.Hash) ||
+				version != want.version {
+				t.Errorf("#%d in %s = %v %q, want %v %q", tt.pr, loc.Branch.Name, loc.Commit, version, want.commit, want.version)
+			}
+		}
+	}
+
+	if _, _, err := tr.r.LocatePR(9); err == nil {
+		t.Errorf("LocatePR of a missing PR succeeded")
+	}
+}
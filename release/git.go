@@ -0,0 +1,121 @@
+package release
+
+import (
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+	gitstorer "gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// CommitTitle returns the first line of the commit message.
+func CommitTitle(commitMsg string) string {
+	title := strings.Split(strings.TrimSpace(commitMsg), "\n")[0] // get the first line
+	return strings.TrimSpace(title)
+}
+
+func (r *Repo) commitForTag(tagName string) (*gitobj.Commit, error) {
+	tag, err := r.repo.Tag(tagName)
+	if err != nil {
+		return nil, repoError("no such version tag: %s", tagName)
+	}
+	commit, err := r.commitForTagRef(tag)
+	if err != nil {
+		return nil, repoError("unable to read the commit of tag %s: %w", tagName, err)
+	}
+	return commit, nil
+}
+
+// commitForTagRef returns the commit that the tag refers to, the tag could be either
+// lightweight or annotated.
+func (r *Repo) commitForTagRef(ref *plumbing.Reference) (*gitobj.Commit, error) {
+	if tagObj, err := r.repo.TagObject(ref.Hash()); err == nil {
+		return tagObj.Commit()
+	}
+	return r.repo.CommitObject(ref.Hash())
+}
+
+// resolveRef returns the commit that the reference points to. `ref` must be a full reference name,
+// "refs/heads/master" or "refs/remotes/origin/v1.12" e.g, HEAD is never implied.
+func (r *Repo) resolveRef(ref plumbing.ReferenceName) (plumbing.Hash, error) {
+	resolved, err := r.repo.Reference(ref, true)
+	if err != nil {
+		return plumbing.ZeroHash, repoError("no such reference %s: %w", ref, err)
+	}
+	return resolved.Hash(), nil
+}
+
+// commitForRef returns the commit that the reference points to, see resolveRef.
+func (r *Repo) commitForRef(ref plumbing.ReferenceName) (*gitobj.Commit, error) {
+	hash, err := r.resolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.repo.CommitObject(hash)
+	if err != nil {
+		return nil, repoError("unable to find commit %s of %s: %w", hash, ref, err)
+	}
+	return commit, nil
+}
+
+// findEqualCommitInRef searches the history of `ref` for the commit equal to `commit`,
+// see findEqualCommitFrom.
+func (r *Repo) findEqualCommitInRef(ref plumbing.ReferenceName, commit *gitobj.Commit) (*gitobj.Commit, bool, error) {
+	idx, err := r.commitIndexOf(ref)
+	if err != nil {
+		return nil, false, err
+	}
+	return idx.findEqualCommitInHistory(commit)
+}
+
+// findPRCommitInRef searches the history of `ref` for the latest commit of the pull-request.
+func (r *Repo) findPRCommitInRef(ref plumbing.ReferenceName, prID int) (*gitobj.Commit, bool, error) {
+	idx, err := r.commitIndexOf(ref)
+	if err != nil {
+		return nil, false, err
+	}
+	positions := idx.byPR[prID]
+	if len(positions) == 0 {
+		return nil, false, nil
+	}
+	commit, err := idx.commit(positions[0])
+	return commit, err == nil, err
+}
+
+// forEachGitLogUntil walks the commit history of `ref` until `stopCommit`.
+func (r *Repo) forEachGitLogUntil(ref plumbing.ReferenceName, handler func(c *gitobj.Commit), stopCommit *gitobj.Commit) error {
+	from, err := r.resolveRef(ref)
+	if err != nil {
+		return err
+	}
+	iter, err := r.repo.Log(&git.LogOptions{From: from})
+	if err != nil {
+		return repoError("unable to perform git log on %s: %w", ref, err)
+	}
+	err = iter.ForEach(func(c *gitobj.Commit) error {
+		if stopCommit != nil {
+			if is, _ := c.IsAncestor(stopCommit); is {
+				return gitstorer.ErrStop
+			}
+		}
+		handler(c)
+		return nil
+	})
+	if err != nil {
+		return repoError("unable to perform git log on %s: %w", ref, err)
+	}
+	return nil
+}
+
+// FindPRCommit searches the master branch for the commit of the pull-request.
+func (r *Repo) FindPRCommit(prID int) (*gitobj.Commit, error) {
+	commit, has, err := r.findPRCommitInRef(r.masterRef(), prID)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, repoError("no such PR in the repo #%d", prID)
+	}
+	return commit, nil
+}
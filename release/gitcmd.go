@@ -0,0 +1,102 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// GitCommandTimeout bounds every git command, in case it hangs on the network or a prompt.
+var GitCommandTimeout = 10 * time.Minute
+
+// GitErrorKind classifies the failures of git commands.
+type GitErrorKind int
+
+const (
+	GitErrUnknown GitErrorKind = iota
+	// the command stopped because of conflicts, a cherry-pick e.g.
+	GitErrConflict
+	// the command refused to run because of the uncommitted changes
+	GitErrDirtyTree
+	// a revision, reference or remote reference doesn't exist
+	GitErrMissingRef
+	// the cherry-pick is empty, since the changes are already applied
+	GitErrEmptyCommit
+)
+
+// GitError is returned when a git command fails.
+type GitError struct {
+	Kind   GitErrorKind
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *GitError) Error() string {
+	return fmt.Sprintf("failed to execute command:\n  git %s\nerror: %s\n%s",
+		strings.Join(e.Args, " "), e.Err, strings.TrimSpace(e.Stderr))
+}
+
+// IsGitError returns whether err is a GitError of the given kind.
+func IsGitError(err error, kind GitErrorKind) bool {
+	var gitErr *GitError
+	return errors.As(err, &gitErr) && gitErr.Kind == kind
+}
+
+// the patterns in stderr to classify the git errors, git is run in C locale so that they're stable
+var gitErrorPatterns = []struct {
+	kind     GitErrorKind
+	patterns []string
+}{
+	{GitErrEmptyCommit, []string{"cherry-pick is now empty", "nothing to commit"}},
+	{GitErrConflict, []string{"CONFLICT", "could not apply", "after resolving the conflicts", "needs merge",
+		"resolve your current index first", "unmerged files", "unresolved conflict"}},
+	{GitErrDirtyTree, []string{"would be overwritten", "commit your changes or stash them",
+		"contains modified or untracked files", "your local changes"}},
+	{GitErrMissingRef, []string{"unknown revision", "bad revision", "not a valid object name", "invalid reference",
+		"couldn't find remote ref", "Needed a single revision", "bad object", "not a valid ref"}},
+}
+
+func classifyGitError(stderr string) GitErrorKind {
+	for _, p := range gitErrorPatterns {
+		for _, pattern := range p.patterns {
+			if strings.Contains(stderr, pattern) {
+				return p.kind
+			}
+		}
+	}
+	return GitErrUnknown
+}
+
+// runGit runs git in `dir` with the arguments, and returns the stdout. The command is killed
+// once ctx is done, or after GitCommandTimeout.
+func (r *Repo) runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, GitCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	r.log.Debugf("run git %s in %s", strings.Join(args, " "), dir)
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		// git reports some errors, like conflicts, in stdout
+		output := stderr.String() + stdout.String()
+		return stdout.String(), &GitError{Kind: classifyGitError(output), Args: args, Stderr: output, Err: err}
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,287 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v28/github"
+	"golang.org/x/oauth2"
+)
+
+// the timeout of each Github API call
+var githubTimeout = 3 * time.Second
+
+// UpcomingRelease is the latest version to be published on Github.
+type UpcomingRelease struct {
+	Version         string
+	PreviousVersion string
+	// pre-released versions are published as Github pre-releases, without labeling the PRs
+	Prerelease bool
+	// the commits released since PreviousVersion
+	Commits []*Commit
+}
+
+// LatestRelease returns the latest tagged version, compared with the released version before it.
+func (r *Repo) LatestRelease() (*UpcomingRelease, error) {
+	latestVer, err := r.LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	latestVerObj, err := r.Conventions.Scheme.ParseVersion(latestVer)
+	if err != nil {
+		return nil, repoError("latest version is invalid to be released: %s: %w", latestVer, err)
+	}
+
+	versions, err := r.Versions(nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(VersionCollection(versions)))
+	var pastReleasedVer *Version
+	for _, v := range versions[1:] {
+		if v.Prerelease() == "" {
+			pastReleasedVer = v
+			break
+		}
+	}
+	if pastReleasedVer == nil {
+		return nil, repoError("no version was released before %s", latestVer)
+	}
+
+	commits, err := r.PickedCommits(pastReleasedVer.Original(), r.BranchOf(latestVer))
+	if err != nil {
+		return nil, err
+	}
+	return &UpcomingRelease{
+		Version:         latestVer,
+		PreviousVersion: pastReleasedVer.Original(),
+		Prerelease:      latestVerObj.Prerelease() != "",
+		Commits:         commits,
+	}, nil
+}
+
+// NewGithubClient creates the client of the Github, or Github Enterprise, where the remote is hosted.
+func (r *Repo) NewGithubClient(ctx context.Context, accessToken string) (*github.Client, error) {
+	remote, err := r.Remote()
+	if err != nil {
+		return nil, err
+	}
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: accessToken},
+	)
+	tc := oauth2.NewClient(ctx, ts)
+	if !remote.IsGithubEnterprise() {
+		return github.NewClient(tc), nil
+	}
+	baseURL := fmt.Sprintf("https://%s/api/v3/", remote.Host)
+	uploadURL := fmt.Sprintf("https://%s/api/uploads/", remote.Host)
+	client, err := github.NewEnterpriseClient(baseURL, uploadURL, tc)
+	if err != nil {
+		return nil, githubError("unable to create client for Github Enterprise %s: %w", remote.Host, err)
+	}
+	return client, nil
+}
+
+// Label returns the Github label of the version or the release branch, see Conventions.LabelFormat.
+func (r *Repo) Label(ver string) (string, error) {
+	tmpl, err := template.New("label").Parse(r.Conventions.LabelFormat)
+	if err != nil {
+		return "", invalidArgumentError("invalid label format '%s': %w", r.Conventions.LabelFormat, err)
+	}
+	var label strings.Builder
+	if err := tmpl.Execute(&label, struct{ Version string }{r.Conventions.Scheme.TrimPrefix(ver)}); err != nil {
+		return "", invalidArgumentError("invalid label format '%s': %w", r.Conventions.LabelFormat, err)
+	}
+	return label.String(), nil
+}
+
+// ReleasePlan is what LabelRelease and PublishRelease would do, see PlanRelease.
+type ReleasePlan struct {
+	// the label of the version, empty if the version is pre-released and no PR would be labeled
+	Label string
+	// whether the label doesn't exist and would be created
+	CreateLabel bool
+	// the pull-requests that would be labeled
+	LabelPRs []int
+	// the pull-requests that are already labeled with a version of the same release branch, by their labels
+	LabeledPRs map[int]string
+	// whether the Github Release exists and would be updated, otherwise it would be created
+	UpdateRelease bool
+}
+
+// PlanRelease returns what LabelRelease and PublishRelease would do, using only the read-only Github APIs.
+func (r *Repo) PlanRelease(ctx context.Context, client *github.Client, ver string, prs []int, prerelease bool) (*ReleasePlan, error) {
+	remote, err := r.Remote()
+	if err != nil {
+		return nil, err
+	}
+	plan := &ReleasePlan{LabeledPRs: make(map[int]string)}
+	if !prerelease {
+		if plan.Label, err = r.Label(ver); err != nil {
+			return nil, err
+		}
+		branchLabel, err := r.Label(r.BranchOf(ver))
+		if err != nil {
+			return nil, err
+		}
+		exists, err := r.labelExists(ctx, client, remote, plan.Label)
+		if err != nil {
+			return nil, err
+		}
+		plan.CreateLabel = !exists
+		for _, prID := range prs {
+			labeled, err := r.prLabelInBranch(ctx, client, remote, prID, branchLabel)
+			if err != nil {
+				return nil, err
+			}
+			if labeled != "" {
+				plan.LabeledPRs[prID] = labeled
+			} else {
+				plan.LabelPRs = append(plan.LabelPRs, prID)
+			}
+		}
+	}
+	existing, err := r.releaseByTag(ctx, client, remote, ver)
+	if err != nil {
+		return nil, err
+	}
+	plan.UpdateRelease = existing != nil
+	return plan, nil
+}
+
+// LabelRelease labels the pull-requests with the version, see Label. The label is created if it
+// doesn't exist. The pull-requests that are already labeled with a version of the same release
+// branch are skipped.
+func (r *Repo) LabelRelease(ctx context.Context, client *github.Client, ver string, prs []int) error {
+	remote, err := r.Remote()
+	if err != nil {
+		return err
+	}
+	newLabel, err := r.Label(ver)
+	if err != nil {
+		return err
+	}
+	// the labels of the versions in the same release branch share this prefix
+	branchLabel, err := r.Label(r.BranchOf(ver))
+	if err != nil {
+		return err
+	}
+
+	exists, err := r.labelExists(ctx, client, remote, newLabel)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		r.log.Infof("create github label %s", newLabel)
+		callCtx, cancel := context.WithTimeout(ctx, githubTimeout)
+		defer cancel()
+		if _, _, err = client.Issues.CreateLabel(callCtx, remote.Owner, remote.Repo, &github.Label{Name: &newLabel}); err != nil {
+			return githubError("unable to create github label %s: %w", newLabel, err)
+		}
+	}
+
+	for _, prID := range prs {
+		if err := r.labelPR(ctx, client, remote, prID, newLabel, branchLabel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repo) labelExists(ctx context.Context, client *github.Client, remote *Remote, label string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
+	_, resp, err := client.Issues.GetLabel(ctx, remote.Owner, remote.Repo, label)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return false, githubError("unable to get github label %s: %w", label, err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// prLabelInBranch returns the label of the pull-request that starts with `branchLabel`, or empty if there's none.
+func (r *Repo) prLabelInBranch(ctx context.Context, client *github.Client, remote *Remote, prID int, branchLabel string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
+	pr, _, err := client.PullRequests.Get(ctx, remote.Owner, remote.Repo, prID)
+	if err != nil {
+		return "", githubError("unable to get pull-request #%d: %w", prID, err)
+	}
+	for _, l := range pr.Labels {
+		if strings.HasPrefix(l.GetName(), branchLabel) {
+			return l.GetName(), nil
+		}
+	}
+	return "", nil
+}
+
+func (r *Repo) labelPR(ctx context.Context, client *github.Client, remote *Remote, prID int, label, branchLabel string) error {
+	labeled, err := r.prLabelInBranch(ctx, client, remote, prID, branchLabel)
+	if err != nil {
+		return err
+	}
+	if labeled != "" {
+		r.log.Infof("#%d is already labeled to %s", prID, labeled)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
+	if _, _, err := client.Issues.AddLabelsToIssue(ctx, remote.Owner, remote.Repo, prID, []string{label}); err != nil {
+		return githubError("unable to add github label %s to #%d: %w", label, prID, err)
+	}
+	r.log.Infof("add github label %s to #%d", label, prID)
+	return nil
+}
+
+// releaseByTag returns the Github Release of the tag, or nil if it doesn't exist.
+func (r *Repo) releaseByTag(ctx context.Context, client *github.Client, remote *Remote, ver string) (*github.RepositoryRelease, error) {
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
+	existing, resp, err := client.Repositories.GetReleaseByTag(ctx, remote.Owner, remote.Repo, ver)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return nil, githubError("unable to get github release %s: %w", ver, err)
+		}
+		return nil, nil
+	}
+	return existing, nil
+}
+
+// PublishRelease creates the Github Release for the tag, or updates it if it exists.
+func (r *Repo) PublishRelease(ctx context.Context, client *github.Client, ver, body string, prerelease bool) error {
+	remote, err := r.Remote()
+	if err != nil {
+		return err
+	}
+	release := &github.RepositoryRelease{
+		TagName:    &ver,
+		Name:       &ver,
+		Body:       &body,
+		Prerelease: &prerelease,
+	}
+	existing, err := r.releaseByTag(ctx, client, remote, ver)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, githubTimeout)
+	defer cancel()
+	if existing == nil {
+		if _, _, err = client.Repositories.CreateRelease(ctx, remote.Owner, remote.Repo, release); err != nil {
+			return githubError("unable to create github release %s: %w", ver, err)
+		}
+		r.log.Infof("create github release %s", ver)
+		return nil
+	}
+	if _, _, err = client.Repositories.EditRelease(ctx, remote.Owner, remote.Repo, existing.GetID(), release); err != nil {
+		return githubError("unable to update github release %s: %w", ver, err)
+	}
+	r.log.Infof("update github release %s", ver)
+	return nil
+}
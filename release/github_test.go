@@ -0,0 +1,65 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v28/github"
+	"gopkg.in/src-d/go-git.v4/config"
+)
+
+func TestPlanRelease(t *testing.T) {
+	// Github with label 1.2.0 missing, #1 labeled as 1.2.0-RC1, #2 unlabeled, and the release v1.2.0 existing
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/XiaoMi/pegasus/labels/", func(w http.ResponseWriter, req *http.Request) {
+		http.NotFound(w, req)
+	})
+	mux.HandleFunc("/repos/XiaoMi/pegasus/pulls/1", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"number": 1, "labels": [{"name": "1.2.0-RC1"}]}`)
+	})
+	mux.HandleFunc("/repos/XiaoMi/pegasus/pulls/2", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"number": 2, "labels": [{"name": "1.1.3"}]}`)
+	})
+	mux.HandleFunc("/repos/XiaoMi/pegasus/releases/tags/v1.2.0", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "tag_name": "v1.2.0"}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s in a plan", req.Method, req.URL.Path)
+		}
+		http.NotFound(w, req)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	tr := newTestRepo(t)
+	_, err := tr.r.repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"git@github.com:XiaoMi/pegasus.git"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := tr.r.PlanRelease(context.Background(), client, "v1.2.0", []int{1, 2}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Label != "1.2.0" || !plan.CreateLabel || !plan.UpdateRelease {
+		t.Errorf("PlanRelease(v1.2.0) = %+v, want to create label 1.2.0 and update the release", plan)
+	}
+	if len(plan.LabelPRs) != 1 || plan.LabelPRs[0] != 2 || plan.LabeledPRs[1] != "1.2.0-RC1" {
+		t.Errorf("PlanRelease(v1.2.0) labels %v, labeled %v, want [2], map[1:1.2.0-RC1]", plan.LabelPRs, plan.LabeledPRs)
+	}
+
+	plan, err = tr.r.PlanRelease(context.Background(), client, "v1.3.0-RC1", []int{1, 2}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Label != "" || len(plan.LabelPRs) != 0 || plan.UpdateRelease {
+		t.Errorf("PlanRelease(v1.3.0-RC1) = %+v, want to create the release only", plan)
+	}
+}
@@ -0,0 +1,134 @@
+package release
+
+import "sort"
+
+// BackportState is the state of a commit in master with respect to a release branch.
+type BackportState int
+
+const (
+	// not cherry-picked to the release branch
+	BackportMissing BackportState = iota
+	// cherry-picked but not released yet
+	BackportPicked
+	// cherry-picked and released
+	BackportReleased
+	// committed before the release branch was forked, so it's in the branch since the beginning
+	BackportForked
+)
+
+// Backport is a commit in master with respect to a release branch.
+type Backport struct {
+	State BackportState
+	// the cherry-pick in the release branch, nil unless it's picked or released
+	Commit *Commit
+}
+
+// BackportRow is a commit in master with respect to each release branch of the matrix.
+type BackportRow struct {
+	Commit    *Commit
+	Backports []*Backport
+}
+
+// BackportMatrix describes where the commits in master are cherry-picked.
+type BackportMatrix struct {
+	// the release branches in ascending order
+	Branches []*ReleaseBranch
+	// the commits in master since the oldest fork point of the branches, the latest first
+	Rows []*BackportRow
+}
+
+// BackportMatrix computes the matrix of the release branches, see BackportState.
+func (r *Repo) BackportMatrix(branches []string) (*BackportMatrix, error) {
+	matrix := &BackportMatrix{}
+	seen := make(map[string]bool)
+	for _, name := range branches {
+		b, err := r.Conventions.Scheme.ParseBranch(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[b.Name] {
+			seen[b.Name] = true
+			matrix.Branches = append(matrix.Branches, b)
+		}
+	}
+	sort.Sort(ReleaseBranchCollection(matrix.Branches))
+
+	masterIdx, err := r.commitIndexOf(r.masterRef())
+	if err != nil {
+		return nil, err
+	}
+	// the commits in master before the fork point of a branch are those in front of it in the index
+	forkPositions := make([]int, len(matrix.Branches))
+	oldestForkPosition := 0
+	for i, b := range matrix.Branches {
+		fork, err := r.ForkPoint(b.Name)
+		if err != nil {
+			return nil, err
+		}
+		pos, ok := masterIdx.byHash[fork.Hash]
+		if !ok {
+			return nil, repoError("the fork point %s of %s is not in %s", fork.Hash.String()[:10], b.Name, r.Conventions.MasterBranch)
+		}
+		forkPositions[i] = pos
+		if pos > oldestForkPosition {
+			oldestForkPosition = pos
+		}
+	}
+
+	for pos := 0; pos < oldestForkPosition; pos++ {
+		c, err := masterIdx.commit(pos)
+		if err != nil {
+			return nil, err
+		}
+		matrix.Rows = append(matrix.Rows, &BackportRow{Commit: r.newCommit(c, "")})
+	}
+	for i, b := range matrix.Branches {
+		fork, err := masterIdx.commit(forkPositions[i])
+		if err != nil {
+			return nil, err
+		}
+		picks, err := r.commitsInBranchFrom(b.Name, fork)
+		if err != nil {
+			return nil, err
+		}
+		picksIdx, limit, ok, err := r.branchIndexSince(b.Name, fork)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if picksIdx, err = r.newCommitIndexFromCommits(picks); err != nil {
+				return nil, err
+			}
+			limit = len(picksIdx.commits)
+		}
+		picksBySHA := make(map[string]*Commit)
+		for _, p := range picks {
+			picksBySHA[p.SHA] = p
+		}
+
+		for pos, row := range matrix.Rows {
+			if pos >= forkPositions[i] {
+				row.Backports = append(row.Backports, &Backport{State: BackportForked})
+				continue
+			}
+			c, err := masterIdx.commit(pos)
+			if err != nil {
+				return nil, err
+			}
+			pick, found, err := picksIdx.find(c, limit)
+			if err != nil {
+				return nil, err
+			}
+			backport := &Backport{State: BackportMissing}
+			if found {
+				backport.Commit = picksBySHA[pick.Hash.String()]
+				backport.State = BackportReleased
+				if backport.Commit.Version == "cherry-picked" {
+					backport.State = BackportPicked
+				}
+			}
+			row.Backports = append(row.Backports, backport)
+		}
+	}
+	return matrix, nil
+}
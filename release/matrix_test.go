@@ -0,0 +1,74 @@
+package release
+
+import "testing"
+
+func TestBackportMatrix(t *testing.T) {
+	// master: init - #1 - #2 - #3 - #4
+	//           \          \
+	// v1.0:      \          #2(v1.0.1) - #3
+	// v1.1:                 (v1.1.0-RC0) #4
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("a")})
+	tr.commit("feat: b (#1)", map[string]string{"b.txt": lines("b")})
+	pr2 := tr.commit("fix: c (#2)", map[string]string{"c.txt": lines("c")})
+	pr3 := tr.commit("fix: d (#3)", map[string]string{"d.txt": lines("d")})
+	pr4 := tr.commit("fix: e (#4)", map[string]string{"e.txt": lines("e")})
+	tr.checkout("v1.0", base)
+	pick2 := tr.commit("fix: c (#2)\n\n(cherry picked from commit "+pr2.Hash.String()+")",
+		map[string]string{"c.txt": lines("c")})
+	tr.tag("v1.0.1", pick2)
+	tr.commit("fix: d (#3)\n\n(cherry picked from commit "+pr3.Hash.String()+")", map[string]string{"d.txt": lines("d")})
+	tr.checkout("v1.1", pr2)
+	// tagged by the branch command
+	tr.tag("v1.1.0-RC0", pr2)
+	tr.commit("fix: e (#4)\n\n(cherry picked from commit "+pr4.Hash.String()+")", map[string]string{"e.txt": lines("e")})
+
+	// the branches are deduplicated and sorted
+	matrix, err := tr.r.BackportMatrix([]string{"v1.1", "v1.0", "v1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matrix.Branches) != 2 || matrix.Branches[0].Name != "v1.0" || matrix.Branches[1].Name != "v1.1" {
+		t.Fatalf("BackportMatrix branches = %v, want [v1.0 v1.1]", matrix.Branches)
+	}
+
+	tests := []struct {
+		pr     int
+		states []BackportState
+		// the versions of the backports, "" if there's no cherry-pick
+		versions []string
+	}{
+		{pr: 4, states: []BackportState{BackportMissing, BackportPicked}, versions: []string{"", "cherry-picked"}},
+		{pr: 3, states: []BackportState{BackportPicked, BackportMissing}, versions: []string{"cherry-picked", ""}},
+		{pr: 2, states: []BackportState{BackportReleased, BackportForked}, versions: []string{"v1.0.1", ""}},
+		{pr: 1, states: []BackportState{BackportMissing, BackportForked}, versions: []string{"", ""}},
+	}
+	if len(matrix.Rows) != len(tests) {
+		var prs []int
+		for _, row := range matrix.Rows {
+			prs = append(prs, row.Commit.PR)
+		}
+		t.Fatalf("BackportMatrix rows = %v, want [4 3 2 1]", prs)
+	}
+	for i, tt := range tests {
+		row := matrix.Rows[i]
+		if row.Commit.PR != tt.pr {
+			t.Errorf("row %d = #%d, want #%d", i, row.Commit.PR, tt.pr)
+			continue
+		}
+		for j, b := range row.Backports {
+			version := ""
+			if b.Commit != nil {
+				version = b.Commit.Version
+			}
+			if b.State != tt.states[j] || version != tt.versions[j] {
+				t.Errorf("#%d in %s = %v %q, want %v %q", tt.pr, matrix.Branches[j].Name, b.State, version,
+					tt.states[j], tt.versions[j])
+			}
+		}
+	}
+
+	if _, err := tr.r.BackportMatrix([]string{"v1.9"}); err == nil {
+		t.Errorf("BackportMatrix of a missing branch succeeded")
+	}
+}
@@ -0,0 +1,167 @@
+package release
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultNotesTemplate renders the notes in Markdown, grouped by the conventional-commit types.
+const DefaultNotesTemplate = `## {{.Version}}
+{{range .Groups}}
+### {{.Title}}
+{{range .Notes}}
+- {{if .Breaking}}**BREAKING** {{end}}{{if .Scope}}**{{.Scope}}**: {{end}}{{.Subject}} ({{.PRName}})
+{{- end}}
+{{end}}`
+
+// Notes is the data passed to the notes template.
+type Notes struct {
+	Version         string
+	PreviousVersion string
+	Owner           string
+	Repo            string
+	Groups          []*NoteGroup
+}
+
+// NoteGroup is the notes of a conventional-commit type.
+type NoteGroup struct {
+	Type  string
+	Title string
+	Notes []*Note
+}
+
+// Note is a pull-request in the release notes.
+type Note struct {
+	Type     string
+	Scope    string
+	Subject  string
+	Breaking bool
+	Title    string
+	PR       int
+	PRName   string
+	PRLink   string
+	SHA      string
+}
+
+// the groups of conventional-commit types, in the order they are rendered
+var noteGroupTitles = []struct {
+	typ   string
+	title string
+}{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance Improvements"},
+	{"refactor", "Code Refactoring"},
+	{"docs", "Documentation"},
+	{"test", "Tests"},
+	{"build", "Build System"},
+	{"ci", "Continuous Integration"},
+	{"chore", "Chores"},
+	{"", "Others"},
+}
+
+// matches "feat(bulk-load): xxx", "fix: xxx", "refactor!: xxx"
+var conventionalTitleRegex = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// parseConventionalTitle parses a commit title without PR ID in the format of conventional-commit.
+// The type is empty if the title is not conventional.
+func parseConventionalTitle(title string) (typ, scope, subject string, breaking bool) {
+	match := conventionalTitleRegex.FindStringSubmatch(title)
+	if match == nil {
+		return "", "", title, false
+	}
+	return strings.ToLower(match[1]), match[2], match[4], match[3] == "!"
+}
+
+// GenerateNotes collects the pull-requests that are released in `ver` since the previous
+// released version.
+func (r *Repo) GenerateNotes(ver string) (*Notes, error) {
+	remote, err := r.Remote()
+	if err != nil {
+		return nil, err
+	}
+	verObj, err := r.Conventions.Scheme.ParseVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+	has, err := r.HasVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return nil, repoError("no such version tag: %s", ver)
+	}
+	pastVer, err := r.PreviousReleasedVersion(verObj)
+	if err != nil {
+		return nil, err
+	}
+	if pastVer == nil {
+		return nil, repoError("no version was released before %s", ver)
+	}
+	r.log.Infof("generating release notes between %s and %s", pastVer.Original(), ver)
+
+	groups := make(map[string]*NoteGroup)
+	for _, g := range noteGroupTitles {
+		groups[g.typ] = &NoteGroup{Type: g.typ, Title: g.title}
+	}
+	commits, err := r.PickedCommits(pastVer.Original(), r.BranchOf(ver))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commits {
+		// only the commits released in `ver`, not those picked afterwards
+		cVer, err := r.Conventions.Scheme.ParseVersion(c.Version)
+		if err != nil || cVer.GreaterThan(verObj) || !cVer.GreaterThan(pastVer) {
+			continue
+		}
+		if c.PR == 0 {
+			r.log.Warnf("unable to get PR ID from commit \"%s\"", c.Title)
+			continue
+		}
+		prID, title := c.PR, c.PRTitle
+		typ, scope, subject, breaking := parseConventionalTitle(title)
+		group, ok := groups[typ]
+		if !ok {
+			group = groups[""]
+		}
+		group.Notes = append(group.Notes, &Note{
+			Type:     typ,
+			Scope:    scope,
+			Subject:  subject,
+			Breaking: breaking,
+			Title:    title,
+			PR:       prID,
+			PRName:   remote.PRName(prID),
+			PRLink:   remote.PRLink(prID),
+			SHA:      c.SHA,
+		})
+	}
+
+	notes := &Notes{
+		Version:         ver,
+		PreviousVersion: pastVer.Original(),
+		Owner:           remote.Owner,
+		Repo:            remote.Repo,
+	}
+	for _, g := range noteGroupTitles {
+		if len(groups[g.typ].Notes) != 0 {
+			notes.Groups = append(notes.Groups, groups[g.typ])
+		}
+	}
+	return notes, nil
+}
+
+// RenderNotes renders the notes with the Go template.
+func RenderNotes(w io.Writer, notes *Notes, tmplText string) error {
+	tmpl, err := template.New("notes").Parse(tmplText)
+	if err != nil {
+		return invalidArgumentError("invalid template: %w", err)
+	}
+	if err := tmpl.Execute(w, notes); err != nil {
+		return fmt.Errorf("unable to render release notes: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package release
+
+import "testing"
+
+func TestParseConventionalTitle(t *testing.T) {
+	tests := []struct {
+		title    string
+		typ      string
+		scope    string
+		subject  string
+		breaking bool
+	}{
+		{title: "feat: add x", typ: "feat", subject: "add x"},
+		{title: "fix(meta): fix x", typ: "fix", scope: "meta", subject: "fix x"},
+		{title: "feat(bulk-load): load x", typ: "feat", scope: "bulk-load", subject: "load x"},
+		{title: "refactor!: drop x", typ: "refactor", subject: "drop x", breaking: true},
+		{title: "feat(api)!: change x", typ: "feat", scope: "api", subject: "change x", breaking: true},
+		{title: "Fix:  x", typ: "fix", subject: "x"},
+		{title: "update the readme", subject: "update the readme"},
+		{title: "fix x: y", subject: "fix x: y"},
+	}
+	for _, tt := range tests {
+		typ, scope, subject, breaking := parseConventionalTitle(tt.title)
+		if typ != tt.typ || scope != tt.scope || subject != tt.subject || breaking != tt.breaking {
+			t.Errorf("parseConventionalTitle(%q) = %q, %q, %q, %v, want %q, %q, %q, %v", tt.title, typ, scope, subject,
+				breaking, tt.typ, tt.scope, tt.subject, tt.breaking)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package release
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PRExtractor extracts the pull-request number from a commit message. The extractors in
+// Conventions.PRExtractors are tried in order, the first match wins.
+type PRExtractor interface {
+	// ExtractPR returns the number and the title of the pull-request, or false if the
+	// message doesn't follow the convention.
+	ExtractPR(msg string) (pr int, title string, ok bool)
+}
+
+// DefaultPRExtractors returns the extractors of the squash-merged, merged, and
+// "PR: #N" trailed commits, in this order.
+func DefaultPRExtractors() []PRExtractor {
+	return []PRExtractor{SquashSuffix{}, MergeSubject{}, Trailer{Key: "PR"}}
+}
+
+// ParsePRExtractor parses the extractor from its specification, which is one of:
+//
+//	squash          SquashSuffix
+//	merge           MergeSubject
+//	trailer         Trailer of "PR: #N"
+//	trailer:<key>   Trailer of "<key>: #N"
+//	regex:<regex>   Regex
+func ParsePRExtractor(spec string) (PRExtractor, error) {
+	kind, arg := spec, ""
+	if i := strings.Index(spec, ":"); i != -1 {
+		kind, arg = spec[:i], spec[i+1:]
+	}
+	switch {
+	case kind == "squash" && arg == "":
+		return SquashSuffix{}, nil
+	case kind == "merge" && arg == "":
+		return MergeSubject{}, nil
+	case kind == "trailer" && spec == "trailer":
+		return Trailer{Key: "PR"}, nil
+	case kind == "trailer" && arg != "":
+		return Trailer{Key: arg}, nil
+	case kind == "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, invalidArgumentError("invalid PR extractor '%s': %w", spec, err)
+		}
+		if re.NumSubexp() == 0 {
+			return nil, invalidArgumentError("invalid PR extractor '%s': no group to capture the PR number", spec)
+		}
+		return Regex{Regexp: re}, nil
+	}
+	return nil, invalidArgumentError("invalid PR extractor '%s', must be squash, merge, trailer[:<key>] or regex:<regex>", spec)
+}
+
+var squashSuffixRegex = regexp.MustCompile(`^(.*?)\s*\(#(\d+)\)$`)
+
+// SquashSuffix extracts from the title of a squash-merged commit, 99 for "fix(meta): x (part 2) (#99)" e.g.
+type SquashSuffix struct{}
+
+// ExtractPR implements PRExtractor.
+func (SquashSuffix) ExtractPR(msg string) (int, string, bool) {
+	match := squashSuffixRegex.FindStringSubmatch(CommitTitle(msg))
+	if match == nil {
+		return 0, "", false
+	}
+	pr, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, "", false
+	}
+	return pr, match[1], true
+}
+
+var mergeSubjectRegex = regexp.MustCompile(`^Merge pull request #(\d+) from \S+`)
+
+// MergeSubject extracts from the merge commit of Github, "Merge pull request #99 from owner/branch" e.g.
+// The title of the pull-request is the first line of the message body.
+type MergeSubject struct{}
+
+// ExtractPR implements PRExtractor.
+func (MergeSubject) ExtractPR(msg string) (int, string, bool) {
+	title := CommitTitle(msg)
+	match := mergeSubjectRegex.FindStringSubmatch(title)
+	if match == nil {
+		return 0, "", false
+	}
+	pr, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, "", false
+	}
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			return pr, line, true
+		}
+	}
+	return pr, title, true
+}
+
+// Trailer extracts from the trailer line of the message, "PR: #99" e.g. The key is case-insensitive,
+// and the "#" is optional.
+type Trailer struct {
+	Key string
+}
+
+// ExtractPR implements PRExtractor.
+func (t Trailer) ExtractPR(msg string) (int, string, bool) {
+	prefix := strings.ToLower(t.Key) + ":"
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	// the trailers are at the end of the message, the latest one wins
+	for i := len(lines) - 1; i > 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(strings.ToLower(line), prefix) {
+			continue
+		}
+		value := strings.TrimPrefix(strings.TrimSpace(line[len(prefix):]), "#")
+		if pr, err := strconv.Atoi(value); err == nil {
+			return pr, CommitTitle(msg), true
+		}
+	}
+	return 0, "", false
+}
+
+// Regex extracts with a regular expression matched against the whole message. The PR number
+// is captured by the group named "pr", or the first group. The title is captured by the group
+// named "title", or it's the commit title.
+type Regex struct {
+	Regexp *regexp.Regexp
+}
+
+// ExtractPR implements PRExtractor.
+func (re Regex) ExtractPR(msg string) (int, string, bool) {
+	match := re.Regexp.FindStringSubmatch(msg)
+	if match == nil {
+		return 0, "", false
+	}
+	prIndex, titleIndex := 1, -1
+	for i, name := range re.Regexp.SubexpNames() {
+		switch name {
+		case "pr":
+			prIndex = i
+		case "title":
+			titleIndex = i
+		}
+	}
+	pr, err := strconv.Atoi(strings.TrimPrefix(match[prIndex], "#"))
+	if err != nil {
+		return 0, "", false
+	}
+	title := CommitTitle(msg)
+	if titleIndex != -1 {
+		title = strings.TrimSpace(match[titleIndex])
+	}
+	return pr, title, true
+}
+
+// ExtractPR returns the number and the title of the pull-request that the commit message refers to,
+// see Conventions.PRExtractors.
+func (r *Repo) ExtractPR(msg string) (pr int, title string, ok bool) {
+	extractors := r.Conventions.PRExtractors
+	if len(extractors) == 0 {
+		extractors = DefaultPRExtractors()
+	}
+	for _, e := range extractors {
+		if pr, title, ok = e.ExtractPR(msg); ok {
+			return
+		}
+	}
+	return 0, "", false
+}
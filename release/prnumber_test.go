@@ -0,0 +1,87 @@
+package release
+
+import "testing"
+
+func TestPRExtractors(t *testing.T) {
+	tests := []struct {
+		extractor PRExtractor
+		msg       string
+		pr        int
+		title     string
+		ok        bool
+	}{
+		{SquashSuffix{}, "fix: x (#99)", 99, "fix: x", true},
+		{SquashSuffix{}, "fix(meta): x (part 2) (#99)", 99, "fix(meta): x (part 2)", true},
+		{SquashSuffix{}, "fix: x (#99)\n\n* fix a (#98)\n", 99, "fix: x", true},
+		{SquashSuffix{}, "fix: x #99", 0, "", false},
+		{SquashSuffix{}, "Merge pull request #99 from a/b", 0, "", false},
+		{MergeSubject{}, "Merge pull request #99 from a/fix-x\n\nfix: x\n", 99, "fix: x", true},
+		{MergeSubject{}, "Merge pull request #99 from a/fix-x", 99, "Merge pull request #99 from a/fix-x", true},
+		{MergeSubject{}, "Merge branch 'master' into v1.12", 0, "", false},
+		{Trailer{Key: "PR"}, "fix: x\n\nPR: #99\n", 99, "fix: x", true},
+		{Trailer{Key: "PR"}, "fix: x\n\npr: 99\nPR: #100", 100, "fix: x", true},
+		{Trailer{Key: "Pull-Request"}, "fix: x\n\nPull-Request: #99", 99, "fix: x", true},
+		{Trailer{Key: "PR"}, "PR: #99", 0, "", false},
+		{Trailer{Key: "PR"}, "fix: x\n\nPR: none", 0, "", false},
+	}
+	for _, tt := range tests {
+		pr, title, ok := tt.extractor.ExtractPR(tt.msg)
+		if pr != tt.pr || title != tt.title || ok != tt.ok {
+			t.Errorf("%T.ExtractPR(%q) = %d, %q, %v, want %d, %q, %v", tt.extractor, tt.msg, pr, title, ok,
+				tt.pr, tt.title, tt.ok)
+		}
+	}
+}
+
+func TestParsePRExtractor(t *testing.T) {
+	tests := []struct {
+		spec  string
+		msg   string
+		pr    int
+		title string
+		fails bool
+	}{
+		{spec: "squash", msg: "fix(meta): x (part 2) (#99)", pr: 99, title: "fix(meta): x (part 2)"},
+		{spec: "merge", msg: "Merge pull request #99 from a/b\n\nfix: x", pr: 99, title: "fix: x"},
+		{spec: "trailer", msg: "fix: x\n\nPR: #99", pr: 99, title: "fix: x"},
+		{spec: "trailer:Reviewed-PR", msg: "fix: x\n\nReviewed-PR: 99", pr: 99, title: "fix: x"},
+		{spec: `regex:\[PR-(\d+)\]`, msg: "fix: x [PR-99]", pr: 99, title: "fix: x [PR-99]"},
+		{spec: `regex:^(?P<title>.*) !(?P<pr>\d+)$`, msg: "fix: x !99", pr: 99, title: "fix: x"},
+		{spec: "regex:[", fails: true},
+		{spec: "regex:PR-99", fails: true},
+		{spec: "squash:x", fails: true},
+		{spec: "trailer:", fails: true},
+		{spec: "unknown", fails: true},
+	}
+	for _, tt := range tests {
+		e, err := ParsePRExtractor(tt.spec)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ParsePRExtractor(%q) = %T, want an error", tt.spec, e)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePRExtractor(%q) failed: %s", tt.spec, err)
+			continue
+		}
+		if pr, title, ok := e.ExtractPR(tt.msg); !ok || pr != tt.pr || title != tt.title {
+			t.Errorf("ParsePRExtractor(%q).ExtractPR(%q) = %d, %q, %v, want %d, %q, true", tt.spec, tt.msg, pr, title, ok,
+				tt.pr, tt.title)
+		}
+	}
+}
+
+func TestRepoExtractPR(t *testing.T) {
+	r := &Repo{Conventions: DefaultConventions()}
+	for msg, want := range map[string]int{
+		"fix: x (#99)": 99,
+		"Merge pull request #98 from a/b\n\nfix: x": 98,
+		"fix: x\n\nPR: #97":                         97,
+		"fix: x":                                    0,
+	} {
+		if pr, _, _ := r.ExtractPR(msg); pr != want {
+			t.Errorf("ExtractPR(%q) = %d, want %d", msg, pr, want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Remote is the official repository on Github where releases are made.
+type Remote struct {
+	// the name of the git remote, "origin" e.g.
+	Name string
+	URL  string
+
+	// "github.com", or the host of Github Enterprise
+	Host  string
+	Owner string
+	Repo  string
+}
+
+// Remote resolves the official repository from the git remotes, see Options.Remote.
+func (r *Repo) Remote() (*Remote, error) {
+	if r.remote != nil {
+		return r.remote, nil
+	}
+
+	name := r.remoteName
+	if name == "" {
+		name = r.GitConfig("release-cli", "remote")
+	}
+	if name == "" && r.githubRepo != "" {
+		var err error
+		if name, err = r.remoteOfGithubRepo(); err != nil {
+			return nil, err
+		}
+	}
+	if name == "" {
+		remotes, err := r.repo.Remotes()
+		if err != nil {
+			return nil, repoError("unable to get remotes: %w", err)
+		}
+		names := make(map[string]bool)
+		for _, rm := range remotes {
+			names[rm.Config().Name] = true
+		}
+		switch {
+		case names["upstream"]:
+			name = "upstream"
+		case names["origin"]:
+			name = "origin"
+		case len(remotes) == 1:
+			name = remotes[0].Config().Name
+		default:
+			return nil, invalidArgumentError("unable to detect the remote of the official repository, please specify --remote")
+		}
+		r.log.Debugf("detected remote '%s' as the official repository", name)
+	}
+
+	remote, err := r.repo.Remote(name)
+	if err != nil {
+		return nil, repoError("no such remote '%s': %w", name, err)
+	}
+	if len(remote.Config().URLs) == 0 {
+		return nil, repoError("remote '%s' has no url", name)
+	}
+	rm := &Remote{Name: name, URL: remote.Config().URLs[0]}
+	if rm.Host, rm.Owner, rm.Repo, err = ParseRemoteURL(rm.URL); err != nil {
+		return nil, err
+	}
+	if r.githubRepo != "" && !strings.EqualFold(rm.Owner+"/"+rm.Repo, r.githubRepo) {
+		r.log.Warnf("remote '%s' (%s) doesn't point at %s", name, rm.URL, r.githubRepo)
+	}
+	r.remote = rm
+	return rm, nil
+}
+
+// remoteOfGithubRepo returns the remote whose url points at the Github repository. If there're several,
+// it's "upstream", or "origin", or the first one by name, the same order as Remote detects by names.
+func (r *Repo) remoteOfGithubRepo() (string, error) {
+	remotes, err := r.repo.Remotes()
+	if err != nil {
+		return "", repoError("unable to get remotes: %w", err)
+	}
+	var matched []string
+	for _, rm := range remotes {
+		for _, u := range rm.Config().URLs {
+			if _, owner, repoName, err := ParseRemoteURL(u); err == nil && strings.EqualFold(owner+"/"+repoName, r.githubRepo) {
+				matched = append(matched, rm.Config().Name)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return "", invalidArgumentError("no remote points at %s, please add one or specify --remote", r.githubRepo)
+	}
+	sort.Strings(matched)
+	name := matched[0]
+	for _, preferred := range []string{"upstream", "origin"} {
+		if containsString(matched, preferred) {
+			name = preferred
+			break
+		}
+	}
+	r.log.Debugf("detected remote '%s' pointing at %s as the official repository", name, r.githubRepo)
+	return name, nil
+}
+
+// GitConfig returns the value of "<section>.<key>" in the repository's git config.
+func (r *Repo) GitConfig(section, key string) string {
+	cfg, err := r.repo.Config()
+	if err != nil || cfg.Raw == nil {
+		return ""
+	}
+	return cfg.Raw.Section(section).Option(key)
+}
+
+// IsGithubEnterprise returns whether the repository is hosted on Github Enterprise.
+func (rm *Remote) IsGithubEnterprise() bool {
+	return rm.Host != "github.com"
+}
+
+// PRName returns the name of the pull-request, "xiaomi/pegasus#233" e.g.
+func (rm *Remote) PRName(prID int) string {
+	return fmt.Sprintf("%s/%s#%d", rm.Owner, rm.Repo, prID)
+}
+
+// PRLink returns the web url of the pull-request.
+func (rm *Remote) PRLink(prID int) string {
+	return fmt.Sprintf("https://%s/%s/%s/pull/%d", rm.Host, rm.Owner, rm.Repo, prID)
+}
+
+// ParseRemoteURL parses the git remote url. For example, for "git@github.com:xiaomi/pegasus.git",
+// the host is "github.com", the owner is "xiaomi", the repoName is "pegasus". The supported formats are:
+//
+//	git@github.com:xiaomi/pegasus.git
+//	ssh://git@github.com:22/xiaomi/pegasus.git
+//	https://user@github.com/xiaomi/pegasus/
+//	https://git.company.com/xiaomi/pegasus (Github Enterprise)
+//	/srv/mirror/xiaomi/pegasus.git, file:///srv/mirror/xiaomi/pegasus.git (a local mirror)
+//
+// A local mirror has no host, it's assumed to mirror "github.com", and the owner and the repoName
+// are the last two segments of the path.
+func ParseRemoteURL(remoteURL string) (host string, owner string, repoName string, err error) {
+	var path string
+	colon := strings.Index(remoteURL, ":")
+	switch {
+	case strings.Contains(remoteURL, "://"):
+		u, err := url.Parse(remoteURL)
+		if err != nil {
+			return "", "", "", repoError("invalid remote url '%s': %w", remoteURL, err)
+		}
+		host, path = u.Hostname(), u.Path
+		if host == "" && u.Scheme != "file" {
+			return "", "", "", repoError("invalid remote url '%s': no host", remoteURL)
+		}
+	case colon == -1 || strings.Contains(remoteURL[:colon], "/"):
+		// a local path, as git does, it's not scp-like if there's a slash before the first colon
+		path = remoteURL
+	default:
+		// scp-like syntax: [user@]host:path
+		host, path = remoteURL[:colon], remoteURL[colon+1:]
+		if at := strings.LastIndex(host, "@"); at != -1 {
+			host = host[at+1:]
+		}
+		if host == "" {
+			return "", "", "", repoError("invalid remote url '%s'", remoteURL)
+		}
+	}
+	if host == "" {
+		host = "github.com"
+	}
+
+	path = strings.TrimSuffix(strings.Trim(filepath.ToSlash(path), "/"), ".git")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", "", repoError("unable to get the owner and repo from remote url '%s'", remoteURL)
+	}
+	return strings.ToLower(host), parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+// Fetch fetches the branches and tags of the remote.
+func (r *Repo) Fetch(ctx context.Context) error {
+	remote, err := r.Remote()
+	if err != nil {
+		return err
+	}
+	r.log.Infof("fetching from %s (%s)", remote.Name, remote.URL)
+	if _, err := r.runGit(ctx, r.Path, "fetch", "--tags", remote.Name); err != nil {
+		return repoError("unable to fetch from %s: %w", remote.Name, err)
+	}
+	return nil
+}
+
+// TrackRemote makes the analysis read the tracking branches of the remote, so that the results
+// reflect the state of the official repository, rather than whatever the user last pulled.
+func (r *Repo) TrackRemote() error {
+	remote, err := r.Remote()
+	if err != nil {
+		return err
+	}
+	refs, err := r.repo.References()
+	if err != nil {
+		return repoError("unable to get references: %w", err)
+	}
+	prefix := "refs/remotes/" + remote.Name + "/"
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		if strings.HasPrefix(name, prefix) && ref.Type() == plumbing.HashReference {
+			r.trackedBranches[strings.TrimPrefix(name, prefix)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return repoError("unable to get references: %w", err)
+	}
+	r.trackingRemote = remote.Name
+	r.log.Debugf("reading the tracking branches of %s: %d branches", remote.Name, len(r.trackedBranches))
+	return nil
+}
+
+// branchRef returns the reference where the branch is read from, "refs/heads/v1.12" or
+// "refs/remotes/origin/v1.12" e.g.
+func (r *Repo) branchRef(branch string) plumbing.ReferenceName {
+	if r.trackingRemote == "" {
+		return plumbing.NewBranchReferenceName(branch)
+	}
+	if r.trackedBranches[branch] {
+		return plumbing.NewRemoteReferenceName(r.trackingRemote, branch)
+	}
+	if !r.untrackedBranchesWarned[branch] {
+		r.untrackedBranchesWarned[branch] = true
+		r.log.Warnf("branch %s is not found in remote %s, use the local branch instead", branch, r.trackingRemote)
+	}
+	return plumbing.NewBranchReferenceName(branch)
+}
+
+func (r *Repo) masterRef() plumbing.ReferenceName {
+	return r.branchRef(r.Conventions.MasterBranch)
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package release
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/config"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url                   string
+		host, owner, repoName string
+		fails                 bool
+	}{
+		{url: "git@github.com:XiaoMi/pegasus.git", host: "github.com", owner: "XiaoMi", repoName: "pegasus"},
+		{url: "git@github.com:XiaoMi/pegasus", host: "github.com", owner: "XiaoMi", repoName: "pegasus"},
+		{url: "ssh://git@github.com:22/xiaomi/pegasus.git", host: "github.com", owner: "xiaomi", repoName: "pegasus"},
+		{url: "https://github.com/xiaomi/pegasus.git", host: "github.com", owner: "xiaomi", repoName: "pegasus"},
+		{url: "https://user@GitHub.com/xiaomi/pegasus/", host: "github.com", owner: "xiaomi", repoName: "pegasus"},
+		{url: "https://git.company.com/xiaomi/pegasus", host: "git.company.com", owner: "xiaomi", repoName: "pegasus"},
+		// local mirrors
+		{url: "/srv/mirror/pegasus.git", host: "github.com", owner: "mirror", repoName: "pegasus"},
+		{url: "file:///srv/mirror/xiaomi/pegasus.git", host: "github.com", owner: "xiaomi", repoName: "pegasus"},
+		{url: "../xiaomi/pegasus", host: "github.com", owner: "xiaomi", repoName: "pegasus"},
+		// invalid
+		{url: "pegasus.git", fails: true},
+		{url: "git@github.com:pegasus.git", fails: true},
+		{url: ":xiaomi/pegasus", fails: true},
+		{url: "https:///xiaomi/pegasus", fails: true},
+		{url: "https://github.com/", fails: true},
+	}
+	for _, tt := range tests {
+		host, owner, repoName, err := ParseRemoteURL(tt.url)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ParseRemoteURL(%q) = %q, %q, %q, want an error", tt.url, host, owner, repoName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRemoteURL(%q) failed: %s", tt.url, err)
+			continue
+		}
+		if host != tt.host || owner != tt.owner || repoName != tt.repoName {
+			t.Errorf("ParseRemoteURL(%q) = %q, %q, %q, want %q, %q, %q", tt.url, host, owner, repoName,
+				tt.host, tt.owner, tt.repoName)
+		}
+	}
+}
+
+func TestRemoteDetection(t *testing.T) {
+	tests := []struct {
+		// name=url of the remotes, separated by spaces
+		remotes    string
+		githubRepo string
+		want       string
+		fails      bool
+	}{
+		{remotes: "origin=git@github.com:XiaoMi/pegasus.git", want: "origin"},
+		{remotes: "origin=git@github.com:me/pegasus.git upstream=git@github.com:XiaoMi/pegasus.git", want: "upstream"},
+		{remotes: "mirror=/srv/XiaoMi/pegasus", want: "mirror"},
+		{remotes: "a=git@github.com:me/pegasus.git b=git@github.com:you/pegasus.git", fails: true},
+		{remotes: "origin=git@github.com:XiaoMi/pegasus.git upstream=git@github.com:XiaoMi/pegasus.git",
+			githubRepo: "XiaoMi/pegasus", want: "upstream"},
+		{remotes: "upstream=git@github.com:apache/pegasus.git origin=git@github.com:xiaomi/pegasus.git",
+			githubRepo: "XiaoMi/pegasus", want: "origin"},
+		{remotes: "b=git@github.com:XiaoMi/pegasus.git a=/srv/XiaoMi/pegasus.git origin=git@github.com:me/pegasus.git",
+			githubRepo: "XiaoMi/pegasus", want: "a"},
+		{remotes: "origin=git@github.com:me/pegasus.git", githubRepo: "XiaoMi/pegasus", fails: true},
+	}
+	for _, tt := range tests {
+		tr := newTestRepo(t)
+		tr.r.githubRepo = tt.githubRepo
+		for _, remote := range strings.Fields(tt.remotes) {
+			kv := strings.SplitN(remote, "=", 2)
+			if _, err := tr.r.repo.CreateRemote(&config.RemoteConfig{Name: kv[0], URLs: []string{kv[1]}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rm, err := tr.r.Remote()
+		if tt.fails {
+			if err == nil {
+				t.Errorf("Remote() of [%s] = %s, want an error", tt.remotes, rm.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Remote() of [%s] failed: %s", tt.remotes, err)
+		} else if rm.Name != tt.want {
+			t.Errorf("Remote() of [%s] with %q = %s, want %s", tt.remotes, tt.githubRepo, rm.Name, tt.want)
+		}
+	}
+}
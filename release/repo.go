@@ -0,0 +1,134 @@
+// Package release implements the release workflow in Pegasus's convention: finding the pull-requests
+// that are not yet cherry-picked to a release branch, cherry-picking them, tagging versions, and
+// publishing the releases on Github.
+//
+// In the convention, the pull-requests are squash-merged to master with titles like "fix: xxx (#233)"
+// (see PRExtractor for the others),
+// then cherry-picked to the release branches named "vMAJOR.MINOR", where the versions are tagged as
+// "vMAJOR.MINOR.PATCH[-RCn]" (see VersionScheme for the others).
+package release
+
+import (
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// Logger receives the progress of the operations.
+type Logger interface {
+	Debugf(format string, a ...interface{})
+	Infof(format string, a ...interface{})
+	Warnf(format string, a ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, a ...interface{}) {}
+func (nopLogger) Infof(format string, a ...interface{})  {}
+func (nopLogger) Warnf(format string, a ...interface{})  {}
+
+// Conventions describes how the repository is organized.
+type Conventions struct {
+	// the branch where the pull-requests are merged to
+	MasterBranch string
+	// The Go template of the Github label of a released version, where `.Version` is the version
+	// without the "v" prefix, "{{.Version}}" renders "1.12.3" for v1.12.3 e.g.
+	LabelFormat string
+	// the extractors of the pull-request numbers from the commit messages, tried in order.
+	// Defaults to DefaultPRExtractors.
+	PRExtractors []PRExtractor
+	// how the release branches and the version tags are named
+	Scheme VersionScheme
+}
+
+// DefaultConventions returns the conventions of Pegasus.
+func DefaultConventions() Conventions {
+	return Conventions{MasterBranch: "master", LabelFormat: "{{.Version}}", Scheme: DefaultVersionScheme()}
+}
+
+// Options configures Open.
+type Options struct {
+	// The git remote of the official repository. If empty, it's the "release-cli.remote"
+	// git config, or the remote whose url points at GithubRepo, or "upstream" if it exists,
+	// or "origin", or the only remote.
+	Remote string
+	// the "owner/repo" of the official repository on Github, "XiaoMi/pegasus" e.g, optional
+	GithubRepo string
+	// Defaults to DefaultConventions.
+	Conventions *Conventions
+	// Defaults to discard the logs.
+	Logger Logger
+	// Don't persist the commit indexes under .git/release-cli/cache, see Repo.SaveCache.
+	DisableCache bool
+}
+
+// Repo is a local clone of the repository to release.
+type Repo struct {
+	// the path of the working tree
+	Path        string
+	Conventions Conventions
+
+	repo       *git.Repository
+	log        Logger
+	remoteName string
+	githubRepo string
+	remote     *Remote
+
+	// the remote whose tracking branches (refs/remotes/<remote>/*) are read by the analysis
+	// instead of the local branches, see TrackRemote. Empty means the local branches.
+	trackingRemote          string
+	trackedBranches         map[string]bool
+	untrackedBranchesWarned map[string]bool
+
+	// the indexes of the histories by their tips, see commitIndexOf
+	commitIndexes  map[plumbing.Hash]*commitIndex
+	patchIDCache   map[plumbing.Hash]string
+	patchIDsLoaded bool
+	patchIDsDirty  bool
+	cacheDisabled  bool
+}
+
+// Open opens the repository at `path`.
+func Open(path string, opts Options) (*Repo, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, repoError("cannot open repo '%s': %w", path, err)
+	}
+	return newRepo(path, repo, opts)
+}
+
+// newRepo wraps the opened go-git repository, which is in-memory in the tests e.g.
+func newRepo(path string, repo *git.Repository, opts Options) (*Repo, error) {
+	r := &Repo{
+		Path:                    path,
+		Conventions:             DefaultConventions(),
+		repo:                    repo,
+		log:                     nopLogger{},
+		remoteName:              opts.Remote,
+		githubRepo:              opts.GithubRepo,
+		trackedBranches:         make(map[string]bool),
+		untrackedBranchesWarned: make(map[string]bool),
+		commitIndexes:           make(map[plumbing.Hash]*commitIndex),
+		patchIDCache:            make(map[plumbing.Hash]string),
+		cacheDisabled:           opts.DisableCache,
+	}
+	if opts.GithubRepo != "" && len(strings.Split(opts.GithubRepo, "/")) != 2 {
+		return nil, invalidArgumentError("invalid Github repository '%s', must be owner/repo", opts.GithubRepo)
+	}
+	if opts.Conventions != nil {
+		r.Conventions = *opts.Conventions
+		if err := r.Conventions.Scheme.validate(); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Logger != nil {
+		r.log = opts.Logger
+	}
+	return r, nil
+}
+
+// Git returns the underlying go-git repository.
+func (r *Repo) Git() *git.Repository {
+	return r.repo
+}
@@ -0,0 +1,137 @@
+package release
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// testRepo is an in-memory git repository to build commits on.
+type testRepo struct {
+	t  *testing.T
+	r  *Repo
+	fs billy.Filesystem
+	wt *git.Worktree
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newRepo("", repo, Options{DisableCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, r: r, fs: fs, wt: wt}
+}
+
+// commit writes the files and commits them with the message.
+func (tr *testRepo) commit(msg string, files map[string]string) *gitobj.Commit {
+	for name, content := range files {
+		if err := util.WriteFile(tr.fs, name, []byte(content), 0644); err != nil {
+			tr.t.Fatal(err)
+		}
+		if _, err := tr.wt.Add(name); err != nil {
+			tr.t.Fatal(err)
+		}
+	}
+	hash, err := tr.wt.Commit(msg, &git.CommitOptions{
+		Author: &gitobj.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	c, err := tr.r.repo.CommitObject(hash)
+	if err != nil {
+		tr.t.Fatal(err)
+	}
+	return c
+}
+
+// checkout creates the branch from the commit and switches to it, or switches to the existing branch
+// if `from` is nil.
+func (tr *testRepo) checkout(branch string, from *gitobj.Commit) {
+	opts := &git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(branch)}
+	if from != nil {
+		opts.Hash = from.Hash
+		opts.Create = true
+	}
+	if err := tr.wt.Checkout(opts); err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+// index indexes the commits, which are given in the order of `git log`.
+func (tr *testRepo) index(commits ...*gitobj.Commit) *commitIndex {
+	idx := tr.r.newCommitIndex(commits[0].Hash)
+	for _, c := range commits {
+		idx.add(&indexedCommit{Hash: c.Hash, Message: c.Message})
+	}
+	return idx
+}
+
+func lines(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestNewRepo(t *testing.T) {
+	repo, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	badScheme := DefaultConventions()
+	badScheme.Scheme.RCPrefix = "preview"
+	tests := []struct {
+		opts  Options
+		fails bool
+	}{
+		{opts: Options{}},
+		{opts: Options{GithubRepo: "XiaoMi/pegasus"}},
+		{opts: Options{GithubRepo: "pegasus"}, fails: true},
+		{opts: Options{GithubRepo: "github.com/XiaoMi/pegasus"}, fails: true},
+		{opts: Options{Conventions: &badScheme}, fails: true},
+	}
+	for _, tt := range tests {
+		r, err := newRepo("", repo, tt.opts)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("newRepo(%+v) succeeded, want an error", tt.opts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("newRepo(%+v) failed: %s", tt.opts, err)
+		} else if r.Conventions.MasterBranch != "master" || r.Git() != repo {
+			t.Errorf("newRepo(%+v) = %+v, want the default conventions", tt.opts, r.Conventions)
+		}
+	}
+}
+
+// tag tags the commit with a lightweight tag.
+func (tr *testRepo) tag(name string, c *gitobj.Commit) {
+	if _, err := tr.r.repo.CreateTag(name, c.Hash, nil); err != nil {
+		tr.t.Fatal(err)
+	}
+}
+
+// orphan switches to a new branch without any commits.
+func (tr *testRepo) orphan(branch string) {
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+	if err := tr.r.repo.Storer.SetReference(head); err != nil {
+		tr.t.Fatal(err)
+	}
+}
@@ -0,0 +1,291 @@
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionScheme describes how the release branches and the version tags are named.
+// A release branch is named after "MAJOR.MINOR", and a version tag "MAJOR.MINOR.PATCH[-PRERELEASE]".
+type VersionScheme struct {
+	// the prefix of the release branches, "v" for "v1.12", or "release-" for "release-1.12" e.g.
+	BranchPrefix string
+	// the prefix of the version tags, "v" for "v1.12.3", or empty for "1.12.3" e.g.
+	TagPrefix string
+	// The pre-release stages from the earliest to the latest, "alpha", "beta", "RC" e.g. A pre-release
+	// is a stage optionally followed by a number, "-RC1", "-rc.2" or "-beta" e.g. The stages are
+	// case-insensitive, and the pre-releases of other stages are not recognized as versions.
+	PrereleaseStages []string
+	// the pre-release of the release candidates that are tagged, followed by the number,
+	// "RC" for "-RC1", or "rc." for "-rc.1" e.g.
+	RCPrefix string
+}
+
+// DefaultVersionScheme returns the scheme of Pegasus: branches "v1.12", tags "v1.12.3-RC1".
+func DefaultVersionScheme() VersionScheme {
+	return VersionScheme{
+		BranchPrefix:     "v",
+		TagPrefix:        "v",
+		PrereleaseStages: []string{"alpha", "beta", "RC"},
+		RCPrefix:         "RC",
+	}
+}
+
+// ReleaseBranch is a release branch parsed by the VersionScheme. The branches are ordered by
+// their versions, "v1.9" < "v1.12" e.g.
+type ReleaseBranch struct {
+	Name         string
+	Major, Minor int
+}
+
+// Compare returns -1, 0 or 1 if the branch is less than, equal to, or greater than `o`.
+func (b *ReleaseBranch) Compare(o *ReleaseBranch) int {
+	if c := compareInts(b.Major, o.Major); c != 0 {
+		return c
+	}
+	return compareInts(b.Minor, o.Minor)
+}
+
+// LessThan returns whether the branch is less than `o`.
+func (b *ReleaseBranch) LessThan(o *ReleaseBranch) bool {
+	return b.Compare(o) < 0
+}
+
+// GreaterThan returns whether the branch is greater than `o`.
+func (b *ReleaseBranch) GreaterThan(o *ReleaseBranch) bool {
+	return b.Compare(o) > 0
+}
+
+// ReleaseBranchCollection sorts the release branches in ascending order.
+type ReleaseBranchCollection []*ReleaseBranch
+
+func (c ReleaseBranchCollection) Len() int           { return len(c) }
+func (c ReleaseBranchCollection) Less(i, j int) bool { return c[i].LessThan(c[j]) }
+func (c ReleaseBranchCollection) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Version is a version tag parsed by the VersionScheme.
+type Version struct {
+	Major, Minor, Patch int
+
+	branch     *ReleaseBranch
+	tag        string
+	prerelease string
+	// the index in VersionScheme.PrereleaseStages, -1 if it's released
+	stage     int
+	stageNum  int
+	hasNumber bool
+}
+
+// Original returns the tag name of the version.
+func (v *Version) Original() string {
+	return v.tag
+}
+
+// Branch returns the release branch where the version is tagged.
+func (v *Version) Branch() *ReleaseBranch {
+	return v.branch
+}
+
+// Prerelease returns the pre-release of the version, "RC1" for "v1.12.3-RC1" e.g, or empty if it's released.
+func (v *Version) Prerelease() string {
+	return v.prerelease
+}
+
+// String returns the version without the tag prefix, "1.12.3-RC1" for "v1.12.3-RC1" e.g.
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.prerelease != "" {
+		s += "-" + v.prerelease
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 if the version is less than, equal to, or greater than `o`.
+// A pre-release is less than the release, and the pre-releases are ordered by the stage,
+// then by the number, "RC2" < "RC10" e.g.
+func (v *Version) Compare(o *Version) int {
+	for _, c := range []int{
+		v.branch.Compare(o.branch),
+		compareInts(v.Patch, o.Patch),
+		compareInts(v.releaseOrder(), o.releaseOrder()),
+		compareInts(v.stage, o.stage),
+		compareInts(v.stageNum, o.stageNum),
+	} {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func (v *Version) releaseOrder() int {
+	if v.prerelease == "" {
+		return 1
+	}
+	return 0
+}
+
+// LessThan returns whether the version is less than `o`.
+func (v *Version) LessThan(o *Version) bool {
+	return v.Compare(o) < 0
+}
+
+// GreaterThan returns whether the version is greater than `o`.
+func (v *Version) GreaterThan(o *Version) bool {
+	return v.Compare(o) > 0
+}
+
+// VersionCollection sorts the versions in ascending order.
+type VersionCollection []*Version
+
+func (c VersionCollection) Len() int           { return len(c) }
+func (c VersionCollection) Less(i, j int) bool { return c[i].LessThan(c[j]) }
+func (c VersionCollection) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+var (
+	versionNumberRegex = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?$`)
+	branchNumberRegex  = regexp.MustCompile(`^(\d+)\.(\d+)$`)
+	prereleaseRegex    = regexp.MustCompile(`^([A-Za-z]+)[.-]?(\d*)$`)
+)
+
+// ParseVersion parses the version tag.
+func (s *VersionScheme) ParseVersion(tag string) (*Version, error) {
+	if !strings.HasPrefix(tag, s.TagPrefix) {
+		return nil, invalidArgumentError("invalid version '%s': no prefix '%s'", tag, s.TagPrefix)
+	}
+	match := versionNumberRegex.FindStringSubmatch(tag[len(s.TagPrefix):])
+	if match == nil {
+		return nil, invalidArgumentError("invalid version '%s'", tag)
+	}
+	v := &Version{tag: tag, prerelease: match[4], stage: -1}
+	v.Major, _ = strconv.Atoi(match[1])
+	v.Minor, _ = strconv.Atoi(match[2])
+	v.Patch, _ = strconv.Atoi(match[3])
+	v.branch = s.releaseBranch(v.Major, v.Minor)
+	if v.prerelease == "" {
+		return v, nil
+	}
+	pre := prereleaseRegex.FindStringSubmatch(v.prerelease)
+	if pre != nil {
+		for i, stage := range s.PrereleaseStages {
+			if strings.EqualFold(stage, pre[1]) {
+				v.stage = i
+			}
+		}
+	}
+	if v.stage == -1 {
+		return nil, invalidArgumentError("invalid version '%s': unrecognized pre-release '%s', must be one of: %s",
+			tag, v.prerelease, strings.Join(s.PrereleaseStages, ", "))
+	}
+	if pre[2] != "" {
+		v.stageNum, _ = strconv.Atoi(pre[2])
+		v.hasNumber = true
+	}
+	return v, nil
+}
+
+// ParseBranch parses the release branch from the name of a release branch or a version, with
+// or without the prefix, "v1.12" for "v1.12", "1.12" or "v1.12.3" e.g.
+func (s *VersionScheme) ParseBranch(name string) (*ReleaseBranch, error) {
+	for _, prefix := range []string{s.BranchPrefix, s.TagPrefix, ""} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := name[len(prefix):]
+		match := branchNumberRegex.FindStringSubmatch(rest)
+		if match == nil {
+			match = versionNumberRegex.FindStringSubmatch(rest)
+		}
+		if match != nil {
+			major, _ := strconv.Atoi(match[1])
+			minor, _ := strconv.Atoi(match[2])
+			return s.releaseBranch(major, minor), nil
+		}
+	}
+	return nil, invalidArgumentError("invalid release branch '%s'", name)
+}
+
+func (s *VersionScheme) releaseBranch(major, minor int) *ReleaseBranch {
+	return &ReleaseBranch{Name: s.Branch(major, minor), Major: major, Minor: minor}
+}
+
+// IsReleaseBranch returns whether the branch is named as a release branch.
+func (s *VersionScheme) IsReleaseBranch(branch string) bool {
+	if !strings.HasPrefix(branch, s.BranchPrefix) {
+		return false
+	}
+	return branchNumberRegex.MatchString(branch[len(s.BranchPrefix):])
+}
+
+// Branch returns the name of the release branch of MAJOR.MINOR.
+func (s *VersionScheme) Branch(major, minor int) string {
+	return fmt.Sprintf("%s%d.%d", s.BranchPrefix, major, minor)
+}
+
+// BranchOf returns the release branch of the version, "v1.12" for "v1.12.3", "1.12" or "v1.12" e.g.
+// The name is returned as is if it's not a version.
+func (s *VersionScheme) BranchOf(ver string) string {
+	b, err := s.ParseBranch(ver)
+	if err != nil {
+		return ver
+	}
+	return b.Name
+}
+
+// Tag returns the name of the version tag, `prerelease` could be empty.
+func (s *VersionScheme) Tag(major, minor, patch int, prerelease string) string {
+	tag := fmt.Sprintf("%s%d.%d.%d", s.TagPrefix, major, minor, patch)
+	if prerelease != "" {
+		tag += "-" + prerelease
+	}
+	return tag
+}
+
+// NormalizeTag adds the tag prefix to the version if it's omitted, "v1.12.3" for "1.12.3" e.g.
+func (s *VersionScheme) NormalizeTag(ver string) string {
+	if _, err := s.ParseVersion(ver); err == nil {
+		return ver
+	}
+	if _, err := s.ParseVersion(s.TagPrefix + ver); err == nil {
+		return s.TagPrefix + ver
+	}
+	return ver
+}
+
+// TrimPrefix returns the version or the release branch without the prefix, "1.12.3" for "v1.12.3" e.g.
+func (s *VersionScheme) TrimPrefix(name string) string {
+	if s.IsReleaseBranch(name) {
+		return name[len(s.BranchPrefix):]
+	}
+	return strings.TrimPrefix(name, s.TagPrefix)
+}
+
+// validate checks that the versions tagged in the scheme are recognized by itself.
+func (s *VersionScheme) validate() error {
+	if _, err := s.ParseVersion(s.Tag(1, 0, 0, s.rc(1))); err != nil {
+		return invalidArgumentError("invalid version scheme, the release candidates are not recognized: %w", err)
+	}
+	return nil
+}
+
+// rc returns the pre-release of the n-th release candidate.
+func (s *VersionScheme) rc(n int) string {
+	return s.RCPrefix + strconv.Itoa(n)
+}
+
+// isRC returns whether the version is a release candidate tagged in RCPrefix.
+func (s *VersionScheme) isRC(v *Version) bool {
+	return v.hasNumber && strings.EqualFold(v.prerelease, s.rc(v.stageNum))
+}
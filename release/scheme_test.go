@@ -0,0 +1,127 @@
+package release
+
+import (
+	"sort"
+	"testing"
+)
+
+// the scheme of "release-1.12" branches and "1.12.3-rc.1" tags
+func altVersionScheme() VersionScheme {
+	return VersionScheme{
+		BranchPrefix:     "release-",
+		TagPrefix:        "",
+		PrereleaseStages: []string{"alpha", "beta", "rc"},
+		RCPrefix:         "rc.",
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		scheme     VersionScheme
+		tag        string
+		str        string
+		branch     string
+		prerelease string
+		fails      bool
+	}{
+		{scheme: DefaultVersionScheme(), tag: "v1.12.3", str: "1.12.3", branch: "v1.12"},
+		{scheme: DefaultVersionScheme(), tag: "v1.12.3-RC1", str: "1.12.3-RC1", branch: "v1.12", prerelease: "RC1"},
+		{scheme: DefaultVersionScheme(), tag: "v2.0.0-rc10", str: "2.0.0-rc10", branch: "v2.0", prerelease: "rc10"},
+		{scheme: DefaultVersionScheme(), tag: "v1.12.0-beta", str: "1.12.0-beta", branch: "v1.12", prerelease: "beta"},
+		{scheme: DefaultVersionScheme(), tag: "1.12.3", fails: true},
+		{scheme: DefaultVersionScheme(), tag: "v1.12", fails: true},
+		{scheme: DefaultVersionScheme(), tag: "v1.12.3-preview1", fails: true},
+		{scheme: altVersionScheme(), tag: "1.12.3-rc.2", str: "1.12.3-rc.2", branch: "release-1.12", prerelease: "rc.2"},
+		{scheme: altVersionScheme(), tag: "1.12.3", str: "1.12.3", branch: "release-1.12"},
+	}
+	for _, tt := range tests {
+		v, err := tt.scheme.ParseVersion(tt.tag)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ParseVersion(%q) = %s, want an error", tt.tag, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseVersion(%q) failed: %s", tt.tag, err)
+			continue
+		}
+		if v.Original() != tt.tag || v.String() != tt.str || v.Branch().Name != tt.branch || v.Prerelease() != tt.prerelease {
+			t.Errorf("ParseVersion(%q) = %q, %q, %q, %q, want %q, %q, %q, %q", tt.tag, v.Original(), v.String(),
+				v.Branch().Name, v.Prerelease(), tt.tag, tt.str, tt.branch, tt.prerelease)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		scheme VersionScheme
+		// in ascending order
+		tags []string
+	}{
+		{scheme: DefaultVersionScheme(), tags: []string{
+			"v1.9.0", "v1.11.0-RC1", "v1.11.0", "v1.11.1", "v1.12.0-alpha", "v1.12.0-beta2", "v1.12.0-RC1",
+			"v1.12.0-RC2", "v1.12.0-RC10", "v1.12.0", "v1.12.10", "v2.0.0-RC1",
+		}},
+		{scheme: altVersionScheme(), tags: []string{
+			"1.1.0-rc.1", "1.1.0", "1.9.0-rc.2", "1.9.0-rc.9", "1.9.0-rc.10", "1.9.0",
+		}},
+	}
+	for _, tt := range tests {
+		var versions []*Version
+		for _, tag := range tt.tags {
+			v, err := tt.scheme.ParseVersion(tag)
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) failed: %s", tag, err)
+			}
+			versions = append(versions, v)
+		}
+		for i := range versions {
+			for j := range versions {
+				want := compareInts(i, j)
+				if got := versions[i].Compare(versions[j]); got != want {
+					t.Errorf("%s.Compare(%s) = %d, want %d", tt.tags[i], tt.tags[j], got, want)
+				}
+			}
+		}
+
+		shuffled := append([]*Version{}, versions...)
+		sort.Sort(sort.Reverse(VersionCollection(shuffled)))
+		sort.Sort(VersionCollection(shuffled))
+		for i, v := range shuffled {
+			if v.Original() != tt.tags[i] {
+				t.Errorf("sorted[%d] = %s, want %s", i, v.Original(), tt.tags[i])
+			}
+		}
+	}
+}
+
+func TestParseBranch(t *testing.T) {
+	tests := []struct {
+		scheme VersionScheme
+		name   string
+		branch string
+		fails  bool
+	}{
+		{scheme: DefaultVersionScheme(), name: "v1.12", branch: "v1.12"},
+		{scheme: DefaultVersionScheme(), name: "1.12", branch: "v1.12"},
+		{scheme: DefaultVersionScheme(), name: "v1.12.3", branch: "v1.12"},
+		{scheme: DefaultVersionScheme(), name: "master", fails: true},
+		{scheme: altVersionScheme(), name: "release-1.12", branch: "release-1.12"},
+		{scheme: altVersionScheme(), name: "1.12.3-rc.1", branch: "release-1.12"},
+	}
+	for _, tt := range tests {
+		b, err := tt.scheme.ParseBranch(tt.name)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ParseBranch(%q) = %s, want an error", tt.name, b.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseBranch(%q) failed: %s", tt.name, err)
+		} else if b.Name != tt.branch {
+			t.Errorf("ParseBranch(%q) = %s, want %s", tt.name, b.Name, tt.branch)
+		}
+	}
+}
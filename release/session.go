@@ -0,0 +1,107 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CherryPickSession records the progress of CherryPick, so that it can be resumed after the user
+// resolves a conflict.
+type CherryPickSession struct {
+	// the release branch for cherry-picks
+	Branch string `json:"branch"`
+	// the HEAD of the release branch before the session started
+	OrigHead string `json:"orig_head"`
+	// the worktree where the cherry-picks are performed
+	Worktree string `json:"worktree"`
+	// the pull-requests to be cherry-picked, in order
+	PRs []*SessionPR `json:"prs"`
+	// index of the pull-request in progress, those before it are done
+	Current int `json:"current"`
+}
+
+// SessionPR is a pull-request to be cherry-picked.
+type SessionPR struct {
+	ID    int    `json:"id"`
+	SHA   string `json:"sha"`
+	Title string `json:"title"`
+}
+
+func (r *Repo) gitDir(ctx context.Context) (string, error) {
+	out, err := r.runGit(ctx, r.Path, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (r *Repo) sessionPath(ctx context.Context) (string, error) {
+	gitDir, err := r.gitDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "release-cli", "add-session.json"), nil
+}
+
+// CherryPickSession returns the session in progress, or nil if there's none.
+func (r *Repo) CherryPickSession(ctx context.Context) (*CherryPickSession, error) {
+	path, err := r.sessionPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, repoError("unable to read session %s: %w", path, err)
+	}
+	s := &CherryPickSession{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, repoError("corrupted session %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (r *Repo) saveSession(ctx context.Context, s *CherryPickSession) error {
+	path, err := r.sessionPath(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return repoError("unable to create directory for session: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return repoError("unable to write session %s: %w", path, err)
+	}
+	return nil
+}
+
+func (r *Repo) removeSession(ctx context.Context) error {
+	path, err := r.sessionPath(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return repoError("unable to remove session %s: %w", path, err)
+	}
+	return nil
+}
+
+// isCherryPickInProgress returns whether git is stopped in the middle of a cherry-pick.
+func (r *Repo) isCherryPickInProgress(ctx context.Context, worktree string) bool {
+	out, err := r.runGit(ctx, worktree, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(strings.TrimSpace(out), "CHERRY_PICK_HEAD"))
+	return err == nil
+}
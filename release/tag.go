@@ -0,0 +1,162 @@
+package release
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// TagKind is the kind of the next version to tag.
+type TagKind int
+
+const (
+	// the next release candidate: v1.12.3-RC1 -> v1.12.3-RC2, v1.12.3 -> v1.12.4-RC1
+	TagRC TagKind = iota
+	// the final release of the current release candidate: v1.12.3-RC2 -> v1.12.3
+	TagFinal
+	// the next patch release without release candidates: v1.12.3 -> v1.12.4
+	TagPatch
+)
+
+// NextTag returns the next version to tag at the tip of the release branch, and the tip.
+func (r *Repo) NextTag(releaseBranch string, kind TagKind) (string, plumbing.Hash, error) {
+	branchHead, err := r.resolveRef(r.branchRef(releaseBranch))
+	if err != nil {
+		return "", plumbing.ZeroHash, repoError("no such release branch: %s", releaseBranch)
+	}
+	if local, err := r.resolveRef(plumbing.NewBranchReferenceName(releaseBranch)); err == nil && local != branchHead {
+		r.log.Warnf("local branch %s (%s) differs from %s (%s), push it first if it has the commits to release",
+			releaseBranch, local.String()[:10], r.branchRef(releaseBranch).Short(), branchHead.String()[:10])
+	}
+	tags, err := r.TagsPointingAt(branchHead)
+	if err != nil {
+		return "", plumbing.ZeroHash, err
+	}
+	if len(tags) != 0 {
+		return "", plumbing.ZeroHash, repoError("the tip of %s (%s) is already tagged: %s",
+			releaseBranch, branchHead.String()[:10], strings.Join(tags, ", "))
+	}
+
+	versions, err := r.versionsInBranch(releaseBranch)
+	if err != nil {
+		return "", plumbing.ZeroHash, err
+	}
+	nextVer, err := r.Conventions.Scheme.NextVersion(releaseBranch, versions, kind)
+	if err != nil {
+		return "", plumbing.ZeroHash, err
+	}
+	return nextVer, branchHead, nil
+}
+
+// NextVersion computes the version to be tagged in the release branch, according to the
+// existing versions.
+func (s *VersionScheme) NextVersion(releaseBranch string, versions []*Version, kind TagKind) (string, error) {
+	b, err := s.ParseBranch(releaseBranch)
+	if err != nil {
+		return "", err
+	}
+	major, minor := b.Major, b.Minor
+	if len(versions) == 0 {
+		if kind == TagRC {
+			return s.Tag(major, minor, 0, s.rc(1)), nil
+		}
+		return s.Tag(major, minor, 0, ""), nil
+	}
+	sort.Sort(sort.Reverse(VersionCollection(versions)))
+	latest := versions[0]
+	base := s.Tag(major, minor, latest.Patch, "")
+	nextPatch := latest.Patch + 1
+
+	if latest.Prerelease() == "" {
+		switch kind {
+		case TagFinal:
+			return "", invalidArgumentError("%s is already released, use --rc or --patch for the next version", latest.Original())
+		case TagRC:
+			return s.Tag(major, minor, nextPatch, s.rc(1)), nil
+		default:
+			return s.Tag(major, minor, nextPatch, ""), nil
+		}
+	}
+
+	switch kind {
+	case TagRC:
+		if !s.isRC(latest) {
+			// the first release candidate after the alpha or beta versions
+			return s.Tag(major, minor, latest.Patch, s.rc(1)), nil
+		}
+		return s.Tag(major, minor, latest.Patch, s.rc(latest.stageNum+1)), nil
+	case TagFinal:
+		return base, nil
+	default:
+		return "", invalidArgumentError("%s is still in pre-released state, use --rc or --final", latest.Original())
+	}
+}
+
+// TagsPointingAt returns the names of the tags that refer to the given commit.
+func (r *Repo) TagsPointingAt(commitHash plumbing.Hash) ([]string, error) {
+	tagIter, err := r.repo.Tags()
+	if err != nil {
+		return nil, repoError("unable to list tags: %w", err)
+	}
+	var tags []string
+	err = tagIter.ForEach(func(ref *plumbing.Reference) error {
+		commit, err := r.commitForTagRef(ref)
+		if err != nil {
+			return nil
+		}
+		if commit.Hash == commitHash {
+			tags = append(tags, ref.Name().Short())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, repoError("unable to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// TagOptions configures CreateTag.
+type TagOptions struct {
+	// create an annotated tag
+	Annotate bool
+	// create a GPG-signed tag
+	Sign bool
+	// the message of annotated or signed tag, defaults to "Release <version>"
+	Message string
+}
+
+// CreateTag tags the commit with `name`. The tag is annotated if any of the options is set.
+func (r *Repo) CreateTag(ctx context.Context, name string, commit plumbing.Hash, opts TagOptions) error {
+	args := []string{"tag"}
+	if opts.Annotate || opts.Sign || opts.Message != "" {
+		message := opts.Message
+		if message == "" {
+			message = "Release " + name
+		}
+		if opts.Sign {
+			args = append(args, "-s")
+		} else {
+			args = append(args, "-a")
+		}
+		args = append(args, "-m", message)
+	}
+	args = append(args, name, commit.String())
+	_, err := r.runGit(ctx, r.Path, args...)
+	return err
+}
+
+// Push pushes the branches or tags to the remote.
+func (r *Repo) Push(ctx context.Context, refs ...string) error {
+	remote, err := r.Remote()
+	if err != nil {
+		return err
+	}
+	args := append([]string{"push", remote.Name}, refs...)
+	if _, err := r.runGit(ctx, r.Path, args...); err != nil {
+		return err
+	}
+	r.log.Infof("pushed %s to %s", strings.Join(refs, " and "), remote.Name)
+	return nil
+}
@@ -0,0 +1,55 @@
+package release
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		scheme VersionScheme
+		branch string
+		// the existing versions, separated by spaces
+		tags  string
+		kind  TagKind
+		next  string
+		fails bool
+	}{
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "", kind: TagRC, next: "v1.12.0-RC1"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "", kind: TagPatch, next: "v1.12.0"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.0-RC0", kind: TagRC, next: "v1.12.0-RC1"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3-RC1 v1.12.2", kind: TagRC, next: "v1.12.3-RC2"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3-RC2 v1.12.3-RC10", kind: TagRC, next: "v1.12.3-RC11"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3-RC10 v1.12.3-RC9", kind: TagFinal, next: "v1.12.3"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3-RC2 v1.12.3", kind: TagRC, next: "v1.12.4-RC1"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3", kind: TagPatch, next: "v1.12.4"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.0-beta2", kind: TagRC, next: "v1.12.0-RC1"},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3", kind: TagFinal, fails: true},
+		{scheme: DefaultVersionScheme(), branch: "v1.12", tags: "v1.12.3-RC1", kind: TagPatch, fails: true},
+		{scheme: altVersionScheme(), branch: "release-1.9", tags: "", kind: TagRC, next: "1.9.0-rc.1"},
+		{scheme: altVersionScheme(), branch: "release-1.9", tags: "1.9.0-rc.9", kind: TagRC, next: "1.9.0-rc.10"},
+		{scheme: altVersionScheme(), branch: "release-1.9", tags: "1.9.0-rc.10 1.9.0-rc.2", kind: TagFinal, next: "1.9.0"},
+	}
+	for _, tt := range tests {
+		var versions []*Version
+		for _, tag := range strings.Fields(tt.tags) {
+			v, err := tt.scheme.ParseVersion(tag)
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) failed: %s", tag, err)
+			}
+			versions = append(versions, v)
+		}
+		next, err := tt.scheme.NextVersion(tt.branch, versions, tt.kind)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("NextVersion(%s, [%s], %d) = %s, want an error", tt.branch, tt.tags, tt.kind, next)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NextVersion(%s, [%s], %d) failed: %s", tt.branch, tt.tags, tt.kind, err)
+		} else if next != tt.next {
+			t.Errorf("NextVersion(%s, [%s], %d) = %s, want %s", tt.branch, tt.tags, tt.kind, next, tt.next)
+		}
+	}
+}
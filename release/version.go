@@ -0,0 +1,307 @@
+package release
+
+import (
+	"sort"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	gitobj "gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// BranchOf returns the release branch of the version, see VersionScheme.BranchOf.
+func (r *Repo) BranchOf(ver string) string {
+	return r.Conventions.Scheme.BranchOf(ver)
+}
+
+// Versions returns the versions tagged in the repository, those rejected by `filter` are excluded.
+// The tags that are not versions in the VersionScheme are ignored.
+func (r *Repo) Versions(filter func(*Version) bool) ([]*Version, error) {
+	tagIter, err := r.repo.Tags()
+	if err != nil {
+		return nil, repoError("unable to list tags: %w", err)
+	}
+	var versions []*Version
+	err = tagIter.ForEach(func(ref *plumbing.Reference) error {
+		v, err := r.Conventions.Scheme.ParseVersion(ref.Name().Short())
+		if err != nil {
+			return nil
+		}
+		if filter == nil || filter(v) {
+			versions = append(versions, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, repoError("unable to list tags: %w", err)
+	}
+	return versions, nil
+}
+
+func (r *Repo) versionsInBranch(releaseBranch string) ([]*Version, error) {
+	return r.Versions(func(v *Version) bool {
+		return v.Branch().Name == releaseBranch
+	})
+}
+
+// HasVersion returns whether the version is tagged.
+func (r *Repo) HasVersion(ver string) (bool, error) {
+	versions, err := r.Versions(func(v *Version) bool { return v.Original() == ver })
+	if err != nil {
+		return false, err
+	}
+	return len(versions) != 0, nil
+}
+
+// InitialVersionInBranch returns the first version of the release branch. In Pegasus's convention, the initial
+// version of `1.12` is `1.12.0-RC1`, or `1.12.0-RC0` if the branch is created by CreateReleaseBranch.
+// If there's no RC versions, the initial version is 1.12.0.
+func (r *Repo) InitialVersionInBranch(releaseBranch string) (string, error) {
+	versions, err := r.versionsInBranch(releaseBranch)
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", repoError("there's no version in \"%s\" branch", releaseBranch)
+	}
+	sort.Sort(VersionCollection(versions))
+	return versions[0].Original(), nil
+}
+
+// LatestVersion returns the latest version in the repository, including pre-released versions.
+func (r *Repo) LatestVersion() (string, error) {
+	versions, err := r.Versions(nil)
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", repoError("there's no version tagged in this repo")
+	}
+	sort.Sort(sort.Reverse(VersionCollection(versions)))
+	return versions[0].Original(), nil
+}
+
+// PreviousReleasedVersion returns the greatest released version that is less than `ver`, or nil
+// if there's none.
+func (r *Repo) PreviousReleasedVersion(ver *Version) (*Version, error) {
+	versions, err := r.Versions(nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(VersionCollection(versions)))
+	for _, v := range versions {
+		if len(v.Prerelease()) == 0 && v.LessThan(ver) {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// LatestReleasedVersionUntilBranch returns the latest version released in `releaseBranch` or in the
+// branches before it, not including pre-released versions, or nil if there's none.
+// For example, given v1.11.6, v1.12.0-RC1 and releaseBranch v1.12, this function returns v1.11.6.
+func (r *Repo) LatestReleasedVersionUntilBranch(releaseBranch string) (*Version, error) {
+	branch, err := r.Conventions.Scheme.ParseBranch(releaseBranch)
+	if err != nil {
+		return nil, nil
+	}
+	versions, err := r.Versions(nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(VersionCollection(versions)))
+	for _, v := range versions {
+		if len(v.Prerelease()) != 0 {
+			continue
+		}
+		if v.Branch().GreaterThan(branch) {
+			continue
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
+// ResolveReleaseLine determines the release branch to inspect and the past released version
+// to compare with. Either of them can be empty, which will be inferred from the other, or
+// from the latest version of this repo.
+func (r *Repo) ResolveReleaseLine(branch, pastReleasedVer string) (string, string, error) {
+	if branch == "" {
+		if pastReleasedVer != "" {
+			branch = r.BranchOf(pastReleasedVer)
+		} else {
+			latest, err := r.LatestVersion()
+			if err != nil {
+				return "", "", err
+			}
+			branch = r.BranchOf(latest)
+		}
+	}
+	branch = r.BranchOf(branch)
+	if has, err := r.HasBranch(branch); err != nil {
+		return "", "", err
+	} else if !has {
+		return "", "", repoError("no such release branch: %s", branch)
+	}
+
+	if pastReleasedVer == "" {
+		v, err := r.LatestReleasedVersionUntilBranch(branch)
+		if err != nil {
+			return "", "", err
+		}
+		if v == nil {
+			return "", "", repoError("there's no released version until branch %s", branch)
+		}
+		return branch, v.Original(), nil
+	}
+
+	pastReleasedVer = r.Conventions.Scheme.NormalizeTag(pastReleasedVer)
+	has, err := r.HasVersion(pastReleasedVer)
+	if err != nil {
+		return "", "", err
+	}
+	if !has {
+		return "", "", repoError("no such version tag: %s", pastReleasedVer)
+	}
+	v, _ := r.Conventions.Scheme.ParseVersion(pastReleasedVer)
+	b, err := r.Conventions.Scheme.ParseBranch(branch)
+	if err != nil || v.Branch().GreaterThan(b) {
+		return "", "", invalidArgumentError("version %s is released after branch %s", pastReleasedVer, branch)
+	}
+	return branch, pastReleasedVer, nil
+}
+
+// versionsInReleaseBranch maps each version tagged in the release branch to the title of its commit.
+func (r *Repo) versionsInReleaseBranch(releaseBranch string) (map[string]string, error) {
+	tagIter, err := r.repo.Tags()
+	if err != nil {
+		return nil, repoError("unable to get tags in release branch %s: %w", releaseBranch, err)
+	}
+
+	versions := make(map[string]string)
+	err = tagIter.ForEach(func(ref *plumbing.Reference) error {
+		ver := ref.Name().Short()
+		if v, err := r.Conventions.Scheme.ParseVersion(ver); err != nil || v.Branch().Name != releaseBranch {
+			return nil
+		}
+		commit, err := r.commitForTagRef(ref)
+		if err != nil {
+			r.log.Warnf("unable to find commit for tag %s: %s", ver, err)
+			return nil
+		}
+		versions[ver] = CommitTitle(commit.Message)
+		return nil
+	})
+	if err != nil {
+		return nil, repoError("unable to get tags in release branch %s: %w", releaseBranch, err)
+	}
+	if len(versions) == 0 {
+		return nil, repoError("no version tagged in release branch %s", releaseBranch)
+	}
+	return versions, nil
+}
+
+func (r *Repo) mapCommitTitleToVersion(releaseBranch string) (map[string]string, error) {
+	versions, err := r.versionsInReleaseBranch(releaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	commitTitleToVersion := make(map[string]string)
+	for version, title := range versions {
+		commitTitleToVersion[title] = version
+	}
+	return commitTitleToVersion, nil
+}
+
+// ReleaseBranches returns the release branches in ascending order, including the tracking branches
+// if TrackRemote is called.
+func (r *Repo) ReleaseBranches() ([]*ReleaseBranch, error) {
+	names := make(map[string]bool)
+	for branchName := range r.trackedBranches {
+		names[branchName] = true
+	}
+	iter, err := r.repo.Branches()
+	if err != nil {
+		return nil, repoError("unable to get branches: %w", err)
+	}
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		names[ref.Name().Short()] = true
+		return nil
+	})
+	if err != nil {
+		return nil, repoError("unable to get branches: %w", err)
+	}
+
+	var branches []*ReleaseBranch
+	for name := range names {
+		if !r.Conventions.Scheme.IsReleaseBranch(name) {
+			continue
+		}
+		b, err := r.Conventions.Scheme.ParseBranch(name)
+		if err != nil {
+			continue
+		}
+		branches = append(branches, b)
+	}
+	sort.Sort(ReleaseBranchCollection(branches))
+	return branches, nil
+}
+
+// HasBranch returns whether the release branch exists.
+func (r *Repo) HasBranch(branch string) (bool, error) {
+	b, err := r.Conventions.Scheme.ParseBranch(branch)
+	if err != nil {
+		return false, err
+	}
+	branches, err := r.ReleaseBranches()
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range branches {
+		if existing.Compare(b) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReleaseLine is the state of a release branch.
+type ReleaseLine struct {
+	Branch *ReleaseBranch
+	// the latest version tagged in the branch, nil if there's none
+	LatestVersion *Version
+	// the tip of the branch
+	Tip *gitobj.Commit
+}
+
+// ReleaseLines returns the state of every release branch in ascending order.
+func (r *Repo) ReleaseLines() ([]*ReleaseLine, error) {
+	branches, err := r.ReleaseBranches()
+	if err != nil {
+		return nil, err
+	}
+	versions, err := r.Versions(nil)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(VersionCollection(versions)))
+
+	var lines []*ReleaseLine
+	for _, b := range branches {
+		tipHash, err := r.resolveRef(r.branchRef(b.Name))
+		if err != nil {
+			return nil, err
+		}
+		tip, err := r.repo.CommitObject(tipHash)
+		if err != nil {
+			return nil, repoError("unable to find commit %s: %w", tipHash, err)
+		}
+		line := &ReleaseLine{Branch: b, Tip: tip}
+		for _, v := range versions {
+			if v.Branch().Compare(b) == 0 {
+				line.LatestVersion = v
+				break
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
@@ -0,0 +1,84 @@
+package release
+
+import "testing"
+
+func TestReleaseBranches(t *testing.T) {
+	tr := newTestRepo(t)
+	base := tr.commit("init", map[string]string{"a.txt": lines("a")})
+	for _, b := range []string{"v1.12", "v1.9", "v2.0", "v1.10", "feature"} {
+		tr.checkout(b, base)
+	}
+	tr.tag("v1.9.3", base)
+	tr.tag("v1.12.0-RC1", base)
+
+	branches, err := tr.r.ReleaseBranches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, b := range branches {
+		names = append(names, b.Name)
+	}
+	if want := []string{"v1.9", "v1.10", "v1.12", "v2.0"}; !equalStrings(names, want) {
+		t.Errorf("ReleaseBranches() = %v, want %v", names, want)
+	}
+
+	hasBranchTests := []struct {
+		branch string
+		has    bool
+		fails  bool
+	}{
+		{branch: "v1.9", has: true},
+		{branch: "1.12", has: true},
+		{branch: "v1.11", has: false},
+		{branch: "v1.13", has: false},
+		{branch: "v3.0", has: false},
+		{branch: "feature", fails: true},
+	}
+	for _, tt := range hasBranchTests {
+		has, err := tr.r.HasBranch(tt.branch)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("HasBranch(%s) = %v, want an error", tt.branch, has)
+			}
+		} else if err != nil || has != tt.has {
+			t.Errorf("HasBranch(%s) = %v, %v, want %v", tt.branch, has, err, tt.has)
+		}
+	}
+
+	resolveTests := []struct {
+		branch, version   string
+		wantBranch, wantV string
+		fails             bool
+	}{
+		{wantBranch: "v1.12", wantV: "v1.9.3"},
+		{branch: "1.12", wantBranch: "v1.12", wantV: "v1.9.3"},
+		{version: "v1.9.3", wantBranch: "v1.9", wantV: "v1.9.3"},
+		{branch: "v1.11", fails: true},
+		{branch: "v1.10", version: "v1.9.4", fails: true},
+		{branch: "v1.9", version: "v1.12.0-RC1", fails: true},
+	}
+	for _, tt := range resolveTests {
+		branch, ver, err := tr.r.ResolveReleaseLine(tt.branch, tt.version)
+		if tt.fails {
+			if err == nil {
+				t.Errorf("ResolveReleaseLine(%q, %q) = %s, %s, want an error", tt.branch, tt.version, branch, ver)
+			}
+		} else if err != nil || branch != tt.wantBranch || ver != tt.wantV {
+			t.Errorf("ResolveReleaseLine(%q, %q) = %s, %s, %v, want %s, %s", tt.branch, tt.version, branch, ver, err,
+				tt.wantBranch, tt.wantV)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
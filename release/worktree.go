@@ -0,0 +1,92 @@
+package release
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// The cherry-picks are performed in a dedicated git worktree, so that the working tree and
+// the current branch of the user's clone are never disturbed. The release branch is updated
+// only after all cherry-picks are done.
+
+// createWorktree creates the worktree under <gitdir>/release-cli/worktree with HEAD detached at
+// `commit`. It's next to the session rather than in the temporary directory, so that it survives
+// the tmp cleaners and the reboots while the user is resolving a conflict.
+func (r *Repo) createWorktree(ctx context.Context, commit string) (string, error) {
+	gitDir, err := r.gitDir(ctx)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(gitDir, "release-cli", "worktree")
+	if _, err := os.Stat(path); err == nil {
+		// left by a session that was not cleaned up, there's no session in progress now
+		r.log.Debugf("remove the stale worktree %s", path)
+		if err := r.removeWorktree(ctx, path); err != nil {
+			return "", err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", repoError("unable to create directory for worktree: %w", err)
+	}
+	if _, err := r.runGit(ctx, r.Path, "worktree", "add", "--detach", path, commit); err != nil {
+		os.RemoveAll(path)
+		return "", err
+	}
+	r.log.Debugf("created worktree %s at %s", path, commit)
+	return path, nil
+}
+
+func (r *Repo) removeWorktree(ctx context.Context, path string) error {
+	if _, err := r.runGit(ctx, r.Path, "worktree", "remove", "--force", path); err != nil {
+		// the worktree may have been removed by the user, clean up the administrative files
+		r.log.Warnf("unable to remove worktree %s: %s", path, err)
+		os.RemoveAll(path)
+		_, err = r.runGit(ctx, r.Path, "worktree", "prune")
+		return err
+	}
+	return nil
+}
+
+func (r *Repo) worktreeHead(ctx context.Context, path string) (plumbing.Hash, error) {
+	out, err := r.runGit(ctx, path, "rev-parse", "HEAD")
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return plumbing.NewHash(strings.TrimSpace(out)), nil
+}
+
+// checkBranchNotCheckedOut returns a repo-state error if the branch is checked out in the main worktree
+// or a linked one, whose index and files would be out of sync once the branch is updated.
+func (r *Repo) checkBranchNotCheckedOut(ctx context.Context, branch string) error {
+	out, err := r.runGit(ctx, r.Path, "worktree", "list", "--porcelain")
+	if err != nil {
+		return err
+	}
+	// the worktrees are separated by empty lines, each has "worktree <path>" and "branch <ref>" if it's not detached
+	ref := plumbing.NewBranchReferenceName(branch).String()
+	path := ""
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			path = strings.TrimPrefix(line, "worktree ")
+		} else if line == "branch "+ref {
+			return repoError("branch %s is checked out in '%s', please switch to another branch", branch, path)
+		}
+	}
+	return nil
+}
+
+// updateBranch points the branch to `newHead`, only if it's still at `oldHead`.
+func (r *Repo) updateBranch(ctx context.Context, branch string, newHead string, oldHead string) error {
+	if err := r.checkBranchNotCheckedOut(ctx, branch); err != nil {
+		return err
+	}
+	_, err := r.runGit(ctx, r.Path, "update-ref", plumbing.NewBranchReferenceName(branch).String(), newHead, oldHead)
+	if err != nil {
+		return repoError("unable to update branch %s, was it changed during the cherry-picks? %w", branch, err)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/urfave/cli"
+)
+
+var remoteArg = ""
+var fetchArg = true
+
+var remoteFlag = cli.StringFlag{
+	Name: "remote",
+	Usage: "The git remote of the official repository. Defaults to the 'release-cli.remote' git config, " +
+		"or the remote pointing at --github-repo if it's specified, or 'upstream' if it exists, or 'origin', or the only remote",
+	EnvVar:      "RELEASE_CLI_REMOTE",
+	Destination: &remoteArg,
+}
+
+var fetchFlag = cli.BoolTFlag{
+	Name:        "fetch",
+	Usage:       "Fetch the branches and tags from the remote before the analysis, use --fetch=false to disable",
+	Destination: &fetchArg,
+}
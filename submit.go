@@ -1,136 +1,151 @@
 package main
 
 import (
-	"context"
+	"bytes"
 	"fmt"
 	"os"
-	"sort"
-	"strings"
-	"time"
 
-	"github.com/google/go-github/v28/github"
-	"github.com/hashicorp/go-version"
 	"github.com/olekukonko/tablewriter"
+	"github.com/pegasus-kv/release-cli/release"
 	"github.com/urfave/cli"
-	"golang.org/x/oauth2"
-	git "gopkg.in/src-d/go-git.v4"
 )
 
-// var repoArg = ""
 var accessToken = ""
 
 // ./release-cli submit
 var submitCommand *cli.Command = &cli.Command{
 	Name:  "submit",
-	Usage: "To submit the pull requests to the given release branch",
+	Usage: "To submit the pull requests to the given release branch, and publish the Github Release",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:        "repo",
-			Usage:       "The path where the git repository locates. /home/pegasus eg.",
-			Required:    true,
-			Destination: &repoArg,
-		},
+		repoFlag,
 		&cli.StringFlag{
 			Name:        "access",
 			Usage:       "The access token to github, see https://github.com/settings/tokens",
 			EnvVar:      "ACCESS_TOKEN",
-			Required:    true,
 			Destination: &accessToken,
 		},
+		remoteFlag,
+		fetchFlag,
 	},
 	Action: func(c *cli.Context) error {
-		var err error
-		var repo *git.Repository
-
-		// validate --repo
-		if repo, err = git.PlainOpen(repoArg); err != nil {
-			return fatalError("cannot open repo '%s': %s", repoArg, err)
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
 		}
-
-		latestVer := getLatestVersion(repo)
-		latestVerObj, err := version.NewSemver(latestVer)
+		remote, err := repo.Remote()
 		if err != nil {
-			return fatalError("latest version is invalid to be released: %s, %s", latestVer, err)
+			return err
 		}
-		if latestVerObj.Prerelease() != "" {
-			return fatalError("repo is still in pre-released state: %s", latestVer)
+		if err := syncRemote(repo); err != nil {
+			return err
 		}
 
-		versions := getAllVersions(repo, nil)
-		sort.Sort(sort.Reverse(version.Collection(versions)))
-		var pastReleasedVer *version.Version
-		for _, v := range versions[1:] {
-			if v.Prerelease() == "" {
-				pastReleasedVer = v
-				break
-			}
+		upcoming, err := repo.LatestRelease()
+		if err != nil {
+			return err
 		}
-		infoLog("submitting PRs between %s and %s", pastReleasedVer, latestVer)
+		latestVer := upcoming.Version
+		infoLog("submitting PRs between %s and %s", upcoming.PreviousVersion, latestVer)
 
 		table := tablewriter.NewWriter(os.Stdout)
 		table.SetHeader([]string{"PR", "Title"})
 		table.SetBorder(false)
 		table.SetColWidth(120)
 		var prs []int
-		for _, c := range getAllCommitsPickedForUpcomingRelease(repo, pastReleasedVer.String()) {
-			prID, err := getPrIDInt(c.title)
-			if err != nil {
-				warnLog("unable to get PR ID from commit \"%s\"", c.title)
+		for _, c := range upcoming.Commits {
+			if c.PR == 0 {
+				warnLog("unable to get PR ID from commit \"%s\"", c.Title)
 				continue
 			}
-			table.Append([]string{fmt.Sprintf("#%d", prID), c.title})
-			prs = append(prs, prID)
+			table.Append([]string{fmt.Sprintf("#%d", c.PR), c.Title})
+			prs = append(prs, c.PR)
 		}
 		infoLog("submit %d commits to %s\n", len(prs), latestVer)
 		table.Render()
 		println()
 
-		origin, err := repo.Remote("origin")
-		fatalExitIfNotNil(err)
-		owner, repoName := getOwnerAndRepoFromURL(origin.Config().URLs[0])
+		notes, err := repo.GenerateNotes(latestVer)
+		if err != nil {
+			return err
+		}
+		var body bytes.Buffer
+		if err := release.RenderNotes(&body, notes, release.DefaultNotesTemplate); err != nil {
+			return err
+		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: accessToken},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-		client := github.NewClient(tc)
+		if dryRun {
+			return printSubmitPlan(repo, remote, upcoming, prs, body.String())
+		}
+		if accessToken == "" {
+			return usageError("the access token to github is required, specify it with --access or ACCESS_TOKEN")
+		}
 
-		// find existing label for version
-		newLabel := latestVer[1:] // remove prefixed 'v'
-		ctx, cancel = context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
-		_, resp, err := client.Issues.GetLabel(ctx, owner, repoName, newLabel)
+		client, err := repo.NewGithubClient(appContext, accessToken)
 		if err != nil {
-			if resp.StatusCode == 404 {
-				fmt.Printf("info: create github label %s\n", latestVer)
-				_, _, err = client.Issues.CreateLabel(ctx, owner, repoName, &github.Label{Name: &newLabel})
-			}
-			fatalExitIfNotNil(err)
+			return err
 		}
+		if upcoming.Prerelease {
+			infoLog("%s is pre-released, skip labeling the PRs", latestVer)
+		} else if err := repo.LabelRelease(appContext, client, latestVer, prs); err != nil {
+			return err
+		}
+		return repo.PublishRelease(appContext, client, latestVer, body.String(), upcoming.Prerelease)
+	},
+}
 
-		// Add release label to the specific PR
-		releaseBranchRaw := getBranch(latestVer)[1:] // remove prefixed 'v'
-		for _, prID := range prs {
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-			defer cancel()
-			pr, _, err := client.PullRequests.Get(ctx, owner, repoName, prID)
-			fatalExitIfNotNil(err)
-			labelAdded := false
-			for _, label := range pr.Labels {
-				if strings.HasPrefix(label.GetName(), releaseBranchRaw) {
-					fmt.Printf("info: #%d is already labeled to %s\n", prID, newLabel)
-					labelAdded = true
-					break
-				}
+// printSubmitPlan prints the labels and the Github Release that `submit` would create or update. If the
+// access token is given, the plan is checked against Github with the read-only APIs.
+func printSubmitPlan(repo *release.Repo, remote *release.Remote, upcoming *release.UpcomingRelease, prs []int, body string) error {
+	ver := upcoming.Version
+	releaseAction := "create or update"
+	if accessToken == "" {
+		warnLog("dry run: no access token is given, the labels and the release are not checked against Github")
+		if !upcoming.Prerelease {
+			label, err := repo.Label(ver)
+			if err != nil {
+				return err
+			}
+			infoLog("dry run: would create github label %s on %s/%s if it doesn't exist", label, remote.Owner, remote.Repo)
+			infoLog("dry run: would add github label %s to the %d PRs above unless they're already labeled", label, len(prs))
+		}
+	} else {
+		client, err := repo.NewGithubClient(appContext, accessToken)
+		if err != nil {
+			return err
+		}
+		plan, err := repo.PlanRelease(appContext, client, ver, prs, upcoming.Prerelease)
+		if err != nil {
+			return err
+		}
+		if plan.Label != "" {
+			if plan.CreateLabel {
+				infoLog("dry run: would create github label %s on %s/%s", plan.Label, remote.Owner, remote.Repo)
+			} else {
+				infoLog("dry run: github label %s exists on %s/%s", plan.Label, remote.Owner, remote.Repo)
 			}
-			if !labelAdded {
-				_, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repoName, prID, []string{newLabel})
-				fatalExitIfNotNil(err)
-				fmt.Printf("info: add github label %s to #%d\n", latestVer, prID)
+			infoLog("dry run: would add github label %s to %d PRs", plan.Label, len(plan.LabelPRs))
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"PR", "Plan"})
+			table.SetBorder(false)
+			for _, prID := range prs {
+				action := "add label " + plan.Label
+				if labeled, ok := plan.LabeledPRs[prID]; ok {
+					action = "ignore, already labeled " + labeled
+				}
+				table.Append([]string{remote.PRName(prID), action})
 			}
+			table.Render()
+			fmt.Println()
 		}
-		return nil
-	},
+		releaseAction = "create"
+		if plan.UpdateRelease {
+			releaseAction = "update"
+		}
+	}
+	if upcoming.Prerelease {
+		infoLog("dry run: %s is pre-released, no PR would be labeled", ver)
+	}
+	infoLog("dry run: would %s github release %s (prerelease: %t) with body:", releaseAction, ver, upcoming.Prerelease)
+	fmt.Println(body)
+	return nil
 }
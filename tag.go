@@ -0,0 +1,110 @@
+package main
+
+import (
+	"github.com/pegasus-kv/release-cli/release"
+	"github.com/urfave/cli"
+)
+
+var rcArg = false
+var finalArg = false
+var patchArg = false
+var annotateArg = false
+var signArg = false
+var messageArg = ""
+var pushArg = false
+
+// ./release-cli tag
+var tagCommand *cli.Command = &cli.Command{
+	Name:  "tag",
+	Usage: "Tag the HEAD of the release branch with the next version",
+	Flags: []cli.Flag{
+		repoFlag,
+		cli.StringFlag{
+			Name:        "branch",
+			Usage:       "The release branch to tag. v1.12 eg.",
+			Destination: &branchArg,
+		},
+		cli.BoolFlag{
+			Name:        "rc",
+			Usage:       "Tag the next release candidate: v1.12.3-RC1 -> v1.12.3-RC2, v1.12.3 -> v1.12.4-RC1",
+			Destination: &rcArg,
+		},
+		cli.BoolFlag{
+			Name:        "final",
+			Usage:       "Tag the final release of the current release candidate: v1.12.3-RC2 -> v1.12.3",
+			Destination: &finalArg,
+		},
+		cli.BoolFlag{
+			Name:        "patch",
+			Usage:       "Tag the next patch release without release candidates: v1.12.3 -> v1.12.4",
+			Destination: &patchArg,
+		},
+		cli.BoolFlag{
+			Name:        "annotate",
+			Usage:       "Create an annotated tag",
+			Destination: &annotateArg,
+		},
+		cli.BoolFlag{
+			Name:        "sign",
+			Usage:       "Create a GPG-signed tag",
+			Destination: &signArg,
+		},
+		cli.StringFlag{
+			Name:        "message",
+			Usage:       "The message of annotated or signed tag. Defaults to \"Release <version>\"",
+			Destination: &messageArg,
+		},
+		cli.BoolFlag{
+			Name:        "push",
+			Usage:       "Push the tag to the remote",
+			Destination: &pushArg,
+		},
+		remoteFlag,
+		fetchFlag,
+	},
+	Action: func(c *cli.Context) error {
+		if err := requireFlags(c, "branch"); err != nil {
+			return err
+		}
+		repo, err := openRepo(c)
+		if err != nil {
+			return err
+		}
+		// plan against the official repository, the existing branches and tags may not be pulled
+		if err := syncRemote(repo); err != nil {
+			return err
+		}
+
+		var kind release.TagKind
+		kinds := 0
+		for k, b := range map[release.TagKind]bool{release.TagRC: rcArg, release.TagFinal: finalArg, release.TagPatch: patchArg} {
+			if b {
+				kind = k
+				kinds++
+			}
+		}
+		if kinds != 1 {
+			return usageError("exactly one of --rc, --final and --patch must be specified")
+		}
+
+		releaseBranch := repo.BranchOf(branchArg)
+		nextVer, branchHead, err := repo.NextTag(releaseBranch, kind)
+		if err != nil {
+			return err
+		}
+		infoLog("tag %s (%s) as %s", releaseBranch, branchHead.String()[:10], nextVer)
+		if dryRun {
+			infoLog("dry run: no tag is created")
+			return nil
+		}
+
+		opts := release.TagOptions{Annotate: annotateArg, Sign: signArg, Message: messageArg}
+		if err := repo.CreateTag(appContext, nextVer, branchHead, opts); err != nil {
+			return err
+		}
+		if pushArg {
+			return repo.Push(appContext, nextVer)
+		}
+		return nil
+	},
+}